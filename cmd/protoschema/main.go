@@ -0,0 +1,31 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+
+	"github.com/ninox14/gore-codenames/internal/server"
+)
+
+func main() {
+	out := flag.String("out", "", "file to write the schema to (default stdout)")
+	version := flag.Int("version", server.ProtocolVersion, "protocol version to generate the schema for")
+	flag.Parse()
+
+	js, err := json.MarshalIndent(server.ProtocolSchema(*version), "", "  ")
+	if err != nil {
+		log.Fatalf("failed to marshal schema: %v", err)
+	}
+	js = append(js, '\n')
+
+	if *out == "" {
+		os.Stdout.Write(js)
+		return
+	}
+
+	if err := os.WriteFile(*out, js, 0o644); err != nil {
+		log.Fatalf("failed to write schema: %v", err)
+	}
+}
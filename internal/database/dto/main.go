@@ -1,6 +1,10 @@
 package dto
 
-import "github.com/google/uuid"
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
 
 type TeamColor string
 
@@ -9,14 +13,21 @@ const (
 	TeamColorBlue TeamColor = "blue"
 )
 
+func (TeamColor) JSONSchemaEnum() []any {
+	return []any{TeamColorRed, TeamColorBlue}
+}
+
 type GameStatePlayer struct {
 	ID   uuid.UUID `json:"id"`
 	Name string    `json:"name"`
+	// Ready is set by seated players in the lobby and reset when they move.
+	Ready bool `json:"ready"`
 }
 
 type Clue struct {
-	Word   string `json:"word"`
-	Number int    `json:"number"`
+	Word    string `json:"word"`
+	Number  int    `json:"number"`
+	Guesses []int  `json:"guesses"`
 }
 
 type Team struct {
@@ -40,10 +51,208 @@ type Board struct {
 	WordsByTeam     map[TeamColor][]int `json:"words_by_team"`
 }
 
+type GameSettings struct {
+	// MuteSpymastersInTeamChat keeps captains out of their team channel while
+	// a turn is being played.
+	MuteSpymastersInTeamChat bool `json:"mute_spymasters_in_team_chat"`
+	// MaxPlayersPerTeam and MaxSpectators cap the seats of a game; zero means
+	// no limit. Spectators on the read-only event stream take no seat and are
+	// not counted.
+	MaxPlayersPerTeam int `json:"max_players_per_team"`
+	MaxSpectators     int `json:"max_spectators"`
+}
+
+type GamePhase string
+
+const (
+	GamePhaseLobby    GamePhase = "lobby"
+	GamePhasePlaying  GamePhase = "playing"
+	GamePhaseFinished GamePhase = "finished"
+)
+
+func (GamePhase) JSONSchemaEnum() []any {
+	return []any{GamePhaseLobby, GamePhasePlaying, GamePhaseFinished}
+}
+
+type CardColor string
+
+const (
+	CardColorRed      CardColor = "red"
+	CardColorBlue     CardColor = "blue"
+	CardColorNeutral  CardColor = "neutral"
+	CardColorAssassin CardColor = "assassin"
+)
+
+func (CardColor) JSONSchemaEnum() []any {
+	return []any{CardColorRed, CardColorBlue, CardColorNeutral, CardColorAssassin}
+}
+
+// ReadyCheck is a host's request for every seated player to confirm they are
+// there before ExpiresAt.
+type ReadyCheck struct {
+	ExpiresAt time.Time `json:"expires_at"`
+	// AutoStart starts the game as soon as every seated player is ready.
+	AutoStart bool `json:"auto_start"`
+}
+
+// Turn is the team currently playing. It is nil outside of the playing phase.
+// Once the captain gave a clue it is the last entry of the team's Clues.
+type Turn struct {
+	Team        TeamColor `json:"team"`
+	ClueGiven   bool      `json:"clue_given"`
+	GuessesLeft int       `json:"guesses_left"`
+}
+
 type GameState struct {
+	// Version is incremented by every change and used for compare-and-set.
+	Version    int64               `json:"version"`
 	HostID     uuid.UUID           `json:"host_id"`
 	WordPackID int32               `json:"wordpack_id"`
+	Settings   GameSettings        `json:"settings"`
+	Phase      GamePhase           `json:"phase"`
 	Spectators []GameStatePlayer   `json:"spectators"`
 	Teams      map[TeamColor]*Team `json:"teams"`
 	Board      *Board              `json:"board"`
+	Turn       *Turn               `json:"turn"`
+	Winner     *TeamColor          `json:"winner"`
+	ReadyCheck *ReadyCheck         `json:"ready_check"`
+	// Waitlist holds players who joined while the spectator seats were full,
+	// in the order they get to move up.
+	Waitlist []GameStatePlayer `json:"waitlist"`
+	// Banned players cannot rejoin the game.
+	Banned []GameStatePlayer `json:"banned"`
+}
+
+// TeamOf returns the team the player is seated in, if any.
+func (gs *GameState) TeamOf(playerID uuid.UUID) (TeamColor, bool) {
+	for color, team := range gs.Teams {
+		for _, p := range team.Players {
+			if p.ID == playerID {
+				return color, true
+			}
+		}
+	}
+	return "", false
+}
+
+func (gs *GameState) IsSpectator(playerID uuid.UUID) bool {
+	for _, p := range gs.Spectators {
+		if p.ID == playerID {
+			return true
+		}
+	}
+	return false
+}
+
+// Player returns the player wherever they sit in the game.
+func (gs *GameState) Player(playerID uuid.UUID) (GameStatePlayer, bool) {
+	for _, p := range gs.Spectators {
+		if p.ID == playerID {
+			return p, true
+		}
+	}
+	for _, team := range gs.Teams {
+		for _, p := range team.Players {
+			if p.ID == playerID {
+				return p, true
+			}
+		}
+	}
+	for _, p := range gs.Waitlist {
+		if p.ID == playerID {
+			return p, true
+		}
+	}
+	return GameStatePlayer{}, false
+}
+
+func (gs *GameState) IsWaitlisted(playerID uuid.UUID) bool {
+	for _, p := range gs.Waitlist {
+		if p.ID == playerID {
+			return true
+		}
+	}
+	return false
+}
+
+func (gs *GameState) IsBanned(playerID uuid.UUID) bool {
+	for _, p := range gs.Banned {
+		if p.ID == playerID {
+			return true
+		}
+	}
+	return false
+}
+
+func (gs *GameState) IsCaptain(playerID uuid.UUID) bool {
+	for _, team := range gs.Teams {
+		if team.CaptainID != nil && *team.CaptainID == playerID {
+			return true
+		}
+	}
+	return false
+}
+
+// ColorOf returns the key card colour of the card at idx.
+func (b *Board) ColorOf(idx int) CardColor {
+	for _, a := range b.AssassinIndexs {
+		if a == idx {
+			return CardColorAssassin
+		}
+	}
+	for color, idxs := range b.WordsByTeam {
+		for _, i := range idxs {
+			if i == idx {
+				return CardColor(color)
+			}
+		}
+	}
+	return CardColorNeutral
+}
+
+func (b *Board) IsGuessed(idx int) bool {
+	for _, i := range b.GuessedIndexs {
+		if i == idx {
+			return true
+		}
+	}
+	return false
+}
+
+// Redacted returns a copy of the board that only reveals the owner of cards
+// that have already been guessed, as seen by operatives and spectators.
+func (b *Board) Redacted() *Board {
+	guessed := make(map[int]bool, len(b.GuessedIndexs))
+	for _, idx := range b.GuessedIndexs {
+		guessed[idx] = true
+	}
+	revealed := func(idxs []int) []int {
+		res := make([]int, 0)
+		for _, idx := range idxs {
+			if guessed[idx] {
+				res = append(res, idx)
+			}
+		}
+		return res
+	}
+
+	redacted := *b
+	redacted.CurrentBoard = append([]string{}, b.CurrentBoard...)
+	redacted.GuessedIndexs = append([]int{}, b.GuessedIndexs...)
+	redacted.TurnOrder = append([]TeamColor{}, b.TurnOrder...)
+	redacted.AssassinIndexs = revealed(b.AssassinIndexs)
+	redacted.WordsByTeam = make(map[TeamColor][]int, len(b.WordsByTeam))
+	for color, idxs := range b.WordsByTeam {
+		redacted.WordsByTeam[color] = revealed(idxs)
+	}
+
+	return &redacted
+}
+
+// Redacted returns a copy of the state that is safe to show to spectators.
+func (gs GameState) Redacted() GameState {
+	if gs.Board != nil {
+		gs.Board = gs.Board.Redacted()
+	}
+	return gs
 }
@@ -0,0 +1,9 @@
+-- Postgres cannot drop enum values, so recreate the type without it
+UPDATE games SET status = 'Finished' WHERE status = 'Abandoned';
+
+ALTER TABLE games ALTER COLUMN status DROP DEFAULT;
+ALTER TYPE game_status RENAME TO game_status_old;
+CREATE TYPE game_status AS ENUM ('Initial', 'Started', 'Finished');
+ALTER TABLE games ALTER COLUMN status TYPE game_status USING status::text::game_status;
+ALTER TABLE games ALTER COLUMN status SET DEFAULT 'Initial';
+DROP TYPE game_status_old;
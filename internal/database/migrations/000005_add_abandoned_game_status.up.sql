@@ -0,0 +1,2 @@
+-- Games whose Redis state was collected by the janitor before they finished
+ALTER TYPE game_status ADD VALUE IF NOT EXISTS 'Abandoned';
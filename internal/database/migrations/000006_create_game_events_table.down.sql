@@ -0,0 +1,2 @@
+-- Drop the game events table
+DROP TABLE IF EXISTS game_events;
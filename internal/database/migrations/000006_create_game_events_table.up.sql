@@ -0,0 +1,19 @@
+-- Append-only log of every accepted game action
+CREATE TABLE game_events (
+    id BIGSERIAL PRIMARY KEY,
+    game_id UUID NOT NULL,
+    -- Game state version produced by the event, 0 for the creation
+    seq BIGINT NOT NULL,
+    type VARCHAR(64) NOT NULL,
+    actor_id UUID NOT NULL,
+    actor_name VARCHAR(256) NOT NULL,
+    payload JSONB NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    -- Foreign key constraints
+    CONSTRAINT fk_game_events_game FOREIGN KEY (game_id) REFERENCES games(id) ON DELETE CASCADE,
+    CONSTRAINT uq_game_events_game_seq UNIQUE (game_id, seq)
+);
+
+CREATE INDEX idx_game_events_actor_id ON game_events(actor_id);
+CREATE INDEX idx_game_events_type ON game_events(type);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_games_join_code;
+
+ALTER TABLE games DROP COLUMN IF EXISTS join_code;
@@ -0,0 +1,4 @@
+-- Short room codes for joining over voice chat, cleared once archived
+ALTER TABLE games ADD COLUMN join_code VARCHAR(6);
+
+CREATE UNIQUE INDEX idx_games_join_code ON games(join_code);
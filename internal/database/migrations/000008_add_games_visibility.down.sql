@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_games_lobby;
+
+ALTER TABLE games DROP COLUMN IF EXISTS visibility;
+
+DROP TYPE IF EXISTS game_visibility;
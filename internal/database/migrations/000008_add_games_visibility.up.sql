@@ -0,0 +1,6 @@
+-- Who can find a game: listed in the lobby, by link or code only, or invited only
+CREATE TYPE game_visibility AS ENUM ('public', 'unlisted', 'private');
+
+ALTER TABLE games ADD COLUMN visibility game_visibility NOT NULL DEFAULT 'public';
+
+CREATE INDEX idx_games_lobby ON games(visibility, status, created_at DESC, id DESC);
@@ -0,0 +1,4 @@
+ALTER TABLE games DROP COLUMN IF EXISTS password_hash;
+ALTER TABLE games DROP COLUMN IF EXISTS access_policy;
+
+DROP TYPE IF EXISTS game_access;
@@ -0,0 +1,5 @@
+-- Who can join a game: anyone, anyone with the password, or invited players only
+CREATE TYPE game_access AS ENUM ('open', 'password', 'invite');
+
+ALTER TABLE games ADD COLUMN access_policy game_access NOT NULL DEFAULT 'open';
+ALTER TABLE games ADD COLUMN password_hash TEXT;
@@ -0,0 +1,10 @@
+-- Skill rating per user, used to split teams evenly
+CREATE TABLE player_ratings (
+    user_id UUID PRIMARY KEY NOT NULL,
+    rating INTEGER NOT NULL DEFAULT 1000,
+    games_played INTEGER NOT NULL DEFAULT 0,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    -- Foreign key constraints
+    CONSTRAINT fk_player_ratings_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
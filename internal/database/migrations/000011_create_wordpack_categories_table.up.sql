@@ -0,0 +1,11 @@
+-- Optional category per word, as imported from spreadsheets
+CREATE TABLE wordpack_categories (
+    wordpack_id INTEGER NOT NULL,
+    word TEXT NOT NULL,
+    category TEXT NOT NULL,
+
+    PRIMARY KEY (wordpack_id, word),
+
+    -- Foreign key constraints
+    CONSTRAINT fk_wordpack_categories_wordpack FOREIGN KEY (wordpack_id) REFERENCES wordpacks(id) ON DELETE CASCADE
+);
@@ -0,0 +1,21 @@
+-- name: CreateGameEvent :one
+INSERT INTO game_events (
+    game_id,
+    seq,
+    type,
+    actor_id,
+    actor_name,
+    payload
+) VALUES (
+    $1, $2, $3, $4, $5, $6
+)
+RETURNING *;
+
+-- name: ListGameEvents :many
+SELECT * FROM game_events
+WHERE game_id = $1
+ORDER BY seq;
+
+-- name: CountGameEvents :one
+SELECT COUNT(*) FROM game_events
+WHERE game_id = $1;
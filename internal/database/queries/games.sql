@@ -3,9 +3,13 @@ INSERT INTO games (
     id,
     host_id,
     word_pack_id,
-    game_state
+    game_state,
+    join_code,
+    visibility,
+    access_policy,
+    password_hash
 ) VALUES (
-    $1, $2, $3, $4
+    $1, $2, $3, $4, $5, $6, $7, $8
 )
 RETURNING *;
 
@@ -13,6 +17,10 @@ RETURNING *;
 SELECT * FROM games
 WHERE id = $1;
 
+-- name: GetGameByJoinCode :one
+SELECT * FROM games
+WHERE join_code = $1;
+
 -- name: GetGamesByHost :many
 SELECT * FROM games
 WHERE host_id = $1
@@ -39,6 +47,19 @@ SET game_state = $2
 WHERE id = $1
 RETURNING *;
 
+-- name: UpdateGameHost :exec
+UPDATE games
+SET host_id = $2
+WHERE id = $1;
+
+-- name: ArchiveGame :one
+UPDATE games
+SET status = $2,
+    game_state = $3,
+    join_code = NULL
+WHERE id = $1
+RETURNING *;
+
 -- name: DeleteGame :exec
 DELETE FROM games
 WHERE id = $1;
@@ -61,4 +82,27 @@ WHERE host_id = $1;
 SELECT * FROM games
 WHERE host_id = $1
 AND status = $2
-ORDER BY created_at DESC;
\ No newline at end of file
+ORDER BY created_at DESC;
+
+-- name: ListLobbyGames :many
+SELECT
+    g.id,
+    g.host_id,
+    u.name AS host_name,
+    g.word_pack_id,
+    w.name AS wordpack_name,
+    g.status,
+    g.created_at
+FROM games g
+JOIN users u ON u.id = g.host_id
+JOIN wordpacks w ON w.id = g.word_pack_id
+WHERE g.visibility = 'public'
+AND g.status::text = ANY(@statuses::text[])
+AND (sqlc.narg(wordpack_id)::int IS NULL OR g.word_pack_id = sqlc.narg(wordpack_id)::int)
+AND (sqlc.narg(host_name)::text IS NULL OR u.name ILIKE sqlc.narg(host_name)::text)
+AND (
+    sqlc.narg(cursor_created_at)::timestamp IS NULL
+    OR (g.created_at, g.id) < (sqlc.narg(cursor_created_at)::timestamp, sqlc.narg(cursor_id)::uuid)
+)
+ORDER BY g.created_at DESC, g.id DESC
+LIMIT @max_results;
@@ -0,0 +1,11 @@
+-- name: ListPlayerRatings :many
+SELECT * FROM player_ratings
+WHERE user_id = ANY(@user_ids::uuid[]);
+
+-- name: UpsertPlayerRating :exec
+INSERT INTO player_ratings (user_id, rating, games_played)
+VALUES (@user_id, @rating, 1)
+ON CONFLICT (user_id) DO UPDATE
+SET rating = EXCLUDED.rating,
+    games_played = player_ratings.games_played + 1,
+    updated_at = CURRENT_TIMESTAMP;
@@ -0,0 +1,12 @@
+-- name: DeleteStaleWordpackCategories :exec
+DELETE FROM wordpack_categories
+WHERE wordpack_id = @wordpack_id AND NOT (word = ANY(@words::text[]));
+
+-- name: InsertWordpackCategories :exec
+INSERT INTO wordpack_categories (wordpack_id, word, category)
+SELECT @wordpack_id, unnest(@words::text[]), unnest(@categories::text[]);
+
+-- name: ListWordpackCategories :many
+SELECT * FROM wordpack_categories
+WHERE wordpack_id = $1
+ORDER BY word;
@@ -53,6 +53,10 @@ LIMIT $2 OFFSET $3;
 -- name: CountWordpacks :one
 SELECT COUNT(*) FROM wordpacks;
 
+-- name: CountSearchWordpacks :one
+SELECT COUNT(*) FROM wordpacks
+WHERE name ILIKE $1 OR description ILIKE $1;
+
 -- name: CountWordpacksByUser :one
 SELECT COUNT(*) FROM wordpacks
 WHERE created_by = $1;
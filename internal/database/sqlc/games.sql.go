@@ -9,9 +9,44 @@ import (
 	"context"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/ninox14/gore-codenames/internal/database/dto"
 )
 
+const archiveGame = `-- name: ArchiveGame :one
+UPDATE games
+SET status = $2,
+    game_state = $3,
+    join_code = NULL
+WHERE id = $1
+RETURNING id, host_id, created_at, started_at, status, word_pack_id, game_state, join_code, visibility, access_policy, password_hash
+`
+
+type ArchiveGameParams struct {
+	ID        uuid.UUID      `db:"id" json:"id"`
+	Status    GameStatus     `db:"status" json:"status"`
+	GameState *dto.GameState `db:"game_state" json:"game_state"`
+}
+
+func (q *Queries) ArchiveGame(ctx context.Context, arg ArchiveGameParams) (Game, error) {
+	row := q.db.QueryRow(ctx, archiveGame, arg.ID, arg.Status, arg.GameState)
+	var i Game
+	err := row.Scan(
+		&i.ID,
+		&i.HostID,
+		&i.CreatedAt,
+		&i.StartedAt,
+		&i.Status,
+		&i.WordPackID,
+		&i.GameState,
+		&i.JoinCode,
+		&i.Visibility,
+		&i.AccessPolicy,
+		&i.PasswordHash,
+	)
+	return i, err
+}
+
 const countGamesByHost = `-- name: CountGamesByHost :one
 SELECT COUNT(*) FROM games
 WHERE host_id = $1
@@ -29,18 +64,26 @@ INSERT INTO games (
     id,
     host_id,
     word_pack_id,
-    game_state
+    game_state,
+    join_code,
+    visibility,
+    access_policy,
+    password_hash
 ) VALUES (
-    $1, $2, $3, $4
+    $1, $2, $3, $4, $5, $6, $7, $8
 )
-RETURNING id, host_id, created_at, started_at, status, word_pack_id, game_state
+RETURNING id, host_id, created_at, started_at, status, word_pack_id, game_state, join_code, visibility, access_policy, password_hash
 `
 
 type CreateGameParams struct {
-	ID         uuid.UUID      `db:"id" json:"id"`
-	HostID     uuid.UUID      `db:"host_id" json:"host_id"`
-	WordPackID int32          `db:"word_pack_id" json:"word_pack_id"`
-	GameState  *dto.GameState `db:"game_state" json:"game_state"`
+	ID           uuid.UUID      `db:"id" json:"id"`
+	HostID       uuid.UUID      `db:"host_id" json:"host_id"`
+	WordPackID   int32          `db:"word_pack_id" json:"word_pack_id"`
+	GameState    *dto.GameState `db:"game_state" json:"game_state"`
+	JoinCode     pgtype.Text    `db:"join_code" json:"join_code"`
+	Visibility   GameVisibility `db:"visibility" json:"visibility"`
+	AccessPolicy GameAccess     `db:"access_policy" json:"access_policy"`
+	PasswordHash pgtype.Text    `db:"password_hash" json:"password_hash"`
 }
 
 func (q *Queries) CreateGame(ctx context.Context, arg CreateGameParams) (Game, error) {
@@ -49,6 +92,10 @@ func (q *Queries) CreateGame(ctx context.Context, arg CreateGameParams) (Game, e
 		arg.HostID,
 		arg.WordPackID,
 		arg.GameState,
+		arg.JoinCode,
+		arg.Visibility,
+		arg.AccessPolicy,
+		arg.PasswordHash,
 	)
 	var i Game
 	err := row.Scan(
@@ -59,6 +106,10 @@ func (q *Queries) CreateGame(ctx context.Context, arg CreateGameParams) (Game, e
 		&i.Status,
 		&i.WordPackID,
 		&i.GameState,
+		&i.JoinCode,
+		&i.Visibility,
+		&i.AccessPolicy,
+		&i.PasswordHash,
 	)
 	return i, err
 }
@@ -74,7 +125,7 @@ func (q *Queries) DeleteGame(ctx context.Context, id uuid.UUID) error {
 }
 
 const getGameByID = `-- name: GetGameByID :one
-SELECT id, host_id, created_at, started_at, status, word_pack_id, game_state FROM games
+SELECT id, host_id, created_at, started_at, status, word_pack_id, game_state, join_code, visibility, access_policy, password_hash FROM games
 WHERE id = $1
 `
 
@@ -89,12 +140,40 @@ func (q *Queries) GetGameByID(ctx context.Context, id uuid.UUID) (Game, error) {
 		&i.Status,
 		&i.WordPackID,
 		&i.GameState,
+		&i.JoinCode,
+		&i.Visibility,
+		&i.AccessPolicy,
+		&i.PasswordHash,
+	)
+	return i, err
+}
+
+const getGameByJoinCode = `-- name: GetGameByJoinCode :one
+SELECT id, host_id, created_at, started_at, status, word_pack_id, game_state, join_code, visibility, access_policy, password_hash FROM games
+WHERE join_code = $1
+`
+
+func (q *Queries) GetGameByJoinCode(ctx context.Context, joinCode pgtype.Text) (Game, error) {
+	row := q.db.QueryRow(ctx, getGameByJoinCode, joinCode)
+	var i Game
+	err := row.Scan(
+		&i.ID,
+		&i.HostID,
+		&i.CreatedAt,
+		&i.StartedAt,
+		&i.Status,
+		&i.WordPackID,
+		&i.GameState,
+		&i.JoinCode,
+		&i.Visibility,
+		&i.AccessPolicy,
+		&i.PasswordHash,
 	)
 	return i, err
 }
 
 const getGamesByHost = `-- name: GetGamesByHost :many
-SELECT id, host_id, created_at, started_at, status, word_pack_id, game_state FROM games
+SELECT id, host_id, created_at, started_at, status, word_pack_id, game_state, join_code, visibility, access_policy, password_hash FROM games
 WHERE host_id = $1
 ORDER BY created_at DESC
 `
@@ -116,6 +195,10 @@ func (q *Queries) GetGamesByHost(ctx context.Context, hostID uuid.UUID) ([]Game,
 			&i.Status,
 			&i.WordPackID,
 			&i.GameState,
+			&i.JoinCode,
+			&i.Visibility,
+			&i.AccessPolicy,
+			&i.PasswordHash,
 		); err != nil {
 			return nil, err
 		}
@@ -128,7 +211,7 @@ func (q *Queries) GetGamesByHost(ctx context.Context, hostID uuid.UUID) ([]Game,
 }
 
 const getGamesByHostAndStatus = `-- name: GetGamesByHostAndStatus :many
-SELECT id, host_id, created_at, started_at, status, word_pack_id, game_state FROM games
+SELECT id, host_id, created_at, started_at, status, word_pack_id, game_state, join_code, visibility, access_policy, password_hash FROM games
 WHERE host_id = $1
 AND status = $2
 ORDER BY created_at DESC
@@ -156,6 +239,10 @@ func (q *Queries) GetGamesByHostAndStatus(ctx context.Context, arg GetGamesByHos
 			&i.Status,
 			&i.WordPackID,
 			&i.GameState,
+			&i.JoinCode,
+			&i.Visibility,
+			&i.AccessPolicy,
+			&i.PasswordHash,
 		); err != nil {
 			return nil, err
 		}
@@ -168,7 +255,7 @@ func (q *Queries) GetGamesByHostAndStatus(ctx context.Context, arg GetGamesByHos
 }
 
 const getGamesByStatus = `-- name: GetGamesByStatus :many
-SELECT id, host_id, created_at, started_at, status, word_pack_id, game_state FROM games
+SELECT id, host_id, created_at, started_at, status, word_pack_id, game_state, join_code, visibility, access_policy, password_hash FROM games
 WHERE status = $1
 ORDER BY created_at DESC
 `
@@ -190,6 +277,10 @@ func (q *Queries) GetGamesByStatus(ctx context.Context, status GameStatus) ([]Ga
 			&i.Status,
 			&i.WordPackID,
 			&i.GameState,
+			&i.JoinCode,
+			&i.Visibility,
+			&i.AccessPolicy,
+			&i.PasswordHash,
 		); err != nil {
 			return nil, err
 		}
@@ -202,7 +293,7 @@ func (q *Queries) GetGamesByStatus(ctx context.Context, status GameStatus) ([]Ga
 }
 
 const getGamesByWordPack = `-- name: GetGamesByWordPack :many
-SELECT id, host_id, created_at, started_at, status, word_pack_id, game_state FROM games
+SELECT id, host_id, created_at, started_at, status, word_pack_id, game_state, join_code, visibility, access_policy, password_hash FROM games
 WHERE word_pack_id = $1
 ORDER BY created_at DESC
 `
@@ -224,6 +315,10 @@ func (q *Queries) GetGamesByWordPack(ctx context.Context, wordPackID int32) ([]G
 			&i.Status,
 			&i.WordPackID,
 			&i.GameState,
+			&i.JoinCode,
+			&i.Visibility,
+			&i.AccessPolicy,
+			&i.PasswordHash,
 		); err != nil {
 			return nil, err
 		}
@@ -236,7 +331,7 @@ func (q *Queries) GetGamesByWordPack(ctx context.Context, wordPackID int32) ([]G
 }
 
 const getRecentGames = `-- name: GetRecentGames :many
-SELECT id, host_id, created_at, started_at, status, word_pack_id, game_state FROM games
+SELECT id, host_id, created_at, started_at, status, word_pack_id, game_state, join_code, visibility, access_policy, password_hash FROM games
 ORDER BY created_at DESC
 LIMIT $1
 `
@@ -258,6 +353,88 @@ func (q *Queries) GetRecentGames(ctx context.Context, limit int32) ([]Game, erro
 			&i.Status,
 			&i.WordPackID,
 			&i.GameState,
+			&i.JoinCode,
+			&i.Visibility,
+			&i.AccessPolicy,
+			&i.PasswordHash,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listLobbyGames = `-- name: ListLobbyGames :many
+SELECT
+    g.id,
+    g.host_id,
+    u.name AS host_name,
+    g.word_pack_id,
+    w.name AS wordpack_name,
+    g.status,
+    g.created_at
+FROM games g
+JOIN users u ON u.id = g.host_id
+JOIN wordpacks w ON w.id = g.word_pack_id
+WHERE g.visibility = 'public'
+AND g.status::text = ANY($1::text[])
+AND ($2::int IS NULL OR g.word_pack_id = $2::int)
+AND ($3::text IS NULL OR u.name ILIKE $3::text)
+AND (
+    $4::timestamp IS NULL
+    OR (g.created_at, g.id) < ($4::timestamp, $5::uuid)
+)
+ORDER BY g.created_at DESC, g.id DESC
+LIMIT $6
+`
+
+type ListLobbyGamesParams struct {
+	Statuses        []string         `db:"statuses" json:"statuses"`
+	WordpackID      pgtype.Int4      `db:"wordpack_id" json:"wordpack_id"`
+	HostName        pgtype.Text      `db:"host_name" json:"host_name"`
+	CursorCreatedAt pgtype.Timestamp `db:"cursor_created_at" json:"cursor_created_at"`
+	CursorID        *uuid.UUID       `db:"cursor_id" json:"cursor_id"`
+	MaxResults      int32            `db:"max_results" json:"max_results"`
+}
+
+type ListLobbyGamesRow struct {
+	ID           uuid.UUID        `db:"id" json:"id"`
+	HostID       uuid.UUID        `db:"host_id" json:"host_id"`
+	HostName     string           `db:"host_name" json:"host_name"`
+	WordPackID   int32            `db:"word_pack_id" json:"word_pack_id"`
+	WordpackName string           `db:"wordpack_name" json:"wordpack_name"`
+	Status       GameStatus       `db:"status" json:"status"`
+	CreatedAt    pgtype.Timestamp `db:"created_at" json:"created_at"`
+}
+
+func (q *Queries) ListLobbyGames(ctx context.Context, arg ListLobbyGamesParams) ([]ListLobbyGamesRow, error) {
+	rows, err := q.db.Query(ctx, listLobbyGames,
+		arg.Statuses,
+		arg.WordpackID,
+		arg.HostName,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.MaxResults,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListLobbyGamesRow
+	for rows.Next() {
+		var i ListLobbyGamesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.HostID,
+			&i.HostName,
+			&i.WordPackID,
+			&i.WordpackName,
+			&i.Status,
+			&i.CreatedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -269,11 +446,27 @@ func (q *Queries) GetRecentGames(ctx context.Context, limit int32) ([]Game, erro
 	return items, nil
 }
 
+const updateGameHost = `-- name: UpdateGameHost :exec
+UPDATE games
+SET host_id = $2
+WHERE id = $1
+`
+
+type UpdateGameHostParams struct {
+	ID     uuid.UUID `db:"id" json:"id"`
+	HostID uuid.UUID `db:"host_id" json:"host_id"`
+}
+
+func (q *Queries) UpdateGameHost(ctx context.Context, arg UpdateGameHostParams) error {
+	_, err := q.db.Exec(ctx, updateGameHost, arg.ID, arg.HostID)
+	return err
+}
+
 const updateGameState = `-- name: UpdateGameState :one
 UPDATE games
 SET game_state = $2
 WHERE id = $1
-RETURNING id, host_id, created_at, started_at, status, word_pack_id, game_state
+RETURNING id, host_id, created_at, started_at, status, word_pack_id, game_state, join_code, visibility, access_policy, password_hash
 `
 
 type UpdateGameStateParams struct {
@@ -292,6 +485,10 @@ func (q *Queries) UpdateGameState(ctx context.Context, arg UpdateGameStateParams
 		&i.Status,
 		&i.WordPackID,
 		&i.GameState,
+		&i.JoinCode,
+		&i.Visibility,
+		&i.AccessPolicy,
+		&i.PasswordHash,
 	)
 	return i, err
 }
@@ -304,7 +501,7 @@ SET status = $2,
         ELSE started_at
     END
 WHERE id = $1
-RETURNING id, host_id, created_at, started_at, status, word_pack_id, game_state
+RETURNING id, host_id, created_at, started_at, status, word_pack_id, game_state, join_code, visibility, access_policy, password_hash
 `
 
 type UpdateGameStatusParams struct {
@@ -323,6 +520,10 @@ func (q *Queries) UpdateGameStatus(ctx context.Context, arg UpdateGameStatusPara
 		&i.Status,
 		&i.WordPackID,
 		&i.GameState,
+		&i.JoinCode,
+		&i.Visibility,
+		&i.AccessPolicy,
+		&i.PasswordHash,
 	)
 	return i, err
 }
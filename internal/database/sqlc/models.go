@@ -13,12 +13,56 @@ import (
 	"github.com/ninox14/gore-codenames/internal/database/dto"
 )
 
+type GameAccess string
+
+const (
+	GameAccessOpen     GameAccess = "open"
+	GameAccessPassword GameAccess = "password"
+	GameAccessInvite   GameAccess = "invite"
+)
+
+func (e *GameAccess) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = GameAccess(s)
+	case string:
+		*e = GameAccess(s)
+	default:
+		return fmt.Errorf("unsupported scan type for GameAccess: %T", src)
+	}
+	return nil
+}
+
+type NullGameAccess struct {
+	GameAccess GameAccess `json:"game_access"`
+	Valid      bool       `json:"valid"` // Valid is true if GameAccess is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullGameAccess) Scan(value interface{}) error {
+	if value == nil {
+		ns.GameAccess, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.GameAccess.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullGameAccess) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.GameAccess), nil
+}
+
 type GameStatus string
 
 const (
-	GameStatusInitial  GameStatus = "Initial"
-	GameStatusStarted  GameStatus = "Started"
-	GameStatusFinished GameStatus = "Finished"
+	GameStatusInitial   GameStatus = "Initial"
+	GameStatusStarted   GameStatus = "Started"
+	GameStatusFinished  GameStatus = "Finished"
+	GameStatusAbandoned GameStatus = "Abandoned"
 )
 
 func (e *GameStatus) Scan(src interface{}) error {
@@ -56,14 +100,79 @@ func (ns NullGameStatus) Value() (driver.Value, error) {
 	return string(ns.GameStatus), nil
 }
 
+type GameVisibility string
+
+const (
+	GameVisibilityPublic   GameVisibility = "public"
+	GameVisibilityUnlisted GameVisibility = "unlisted"
+	GameVisibilityPrivate  GameVisibility = "private"
+)
+
+func (e *GameVisibility) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = GameVisibility(s)
+	case string:
+		*e = GameVisibility(s)
+	default:
+		return fmt.Errorf("unsupported scan type for GameVisibility: %T", src)
+	}
+	return nil
+}
+
+type NullGameVisibility struct {
+	GameVisibility GameVisibility `json:"game_visibility"`
+	Valid          bool           `json:"valid"` // Valid is true if GameVisibility is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullGameVisibility) Scan(value interface{}) error {
+	if value == nil {
+		ns.GameVisibility, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.GameVisibility.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullGameVisibility) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.GameVisibility), nil
+}
+
 type Game struct {
-	ID         uuid.UUID        `db:"id" json:"id"`
-	HostID     uuid.UUID        `db:"host_id" json:"host_id"`
-	CreatedAt  pgtype.Timestamp `db:"created_at" json:"created_at"`
-	StartedAt  pgtype.Timestamp `db:"started_at" json:"started_at"`
-	Status     GameStatus       `db:"status" json:"status"`
-	WordPackID int32            `db:"word_pack_id" json:"word_pack_id"`
-	GameState  *dto.GameState   `db:"game_state" json:"game_state"`
+	ID           uuid.UUID        `db:"id" json:"id"`
+	HostID       uuid.UUID        `db:"host_id" json:"host_id"`
+	CreatedAt    pgtype.Timestamp `db:"created_at" json:"created_at"`
+	StartedAt    pgtype.Timestamp `db:"started_at" json:"started_at"`
+	Status       GameStatus       `db:"status" json:"status"`
+	WordPackID   int32            `db:"word_pack_id" json:"word_pack_id"`
+	GameState    *dto.GameState   `db:"game_state" json:"game_state"`
+	JoinCode     pgtype.Text      `db:"join_code" json:"join_code"`
+	Visibility   GameVisibility   `db:"visibility" json:"visibility"`
+	AccessPolicy GameAccess       `db:"access_policy" json:"access_policy"`
+	PasswordHash pgtype.Text      `db:"password_hash" json:"password_hash"`
+}
+
+type GameEvent struct {
+	ID        int64              `db:"id" json:"id"`
+	GameID    uuid.UUID          `db:"game_id" json:"game_id"`
+	Seq       int64              `db:"seq" json:"seq"`
+	Type      string             `db:"type" json:"type"`
+	ActorID   uuid.UUID          `db:"actor_id" json:"actor_id"`
+	ActorName string             `db:"actor_name" json:"actor_name"`
+	Payload   []byte             `db:"payload" json:"payload"`
+	CreatedAt pgtype.Timestamptz `db:"created_at" json:"created_at"`
+}
+
+type PlayerRating struct {
+	UserID      uuid.UUID          `db:"user_id" json:"user_id"`
+	Rating      int32              `db:"rating" json:"rating"`
+	GamesPlayed int32              `db:"games_played" json:"games_played"`
+	UpdatedAt   pgtype.Timestamptz `db:"updated_at" json:"updated_at"`
 }
 
 type User struct {
@@ -80,3 +189,9 @@ type Wordpack struct {
 	IsDefault   pgtype.Bool `db:"is_default" json:"is_default"`
 	Words       []string    `db:"words" json:"words"`
 }
+
+type WordpackCategory struct {
+	WordpackID int32  `db:"wordpack_id" json:"wordpack_id"`
+	Word       string `db:"word" json:"word"`
+	Category   string `db:"category" json:"category"`
+}
@@ -39,6 +39,18 @@ func (q *Queries) CheckWordpackNameExists(ctx context.Context, arg CheckWordpack
 	return exists, err
 }
 
+const countSearchWordpacks = `-- name: CountSearchWordpacks :one
+SELECT COUNT(*) FROM wordpacks
+WHERE name ILIKE $1 OR description ILIKE $1
+`
+
+func (q *Queries) CountSearchWordpacks(ctx context.Context, name string) (int64, error) {
+	row := q.db.QueryRow(ctx, countSearchWordpacks, name)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const countWordpacks = `-- name: CountWordpacks :one
 SELECT COUNT(*) FROM wordpacks
 `
@@ -0,0 +1,181 @@
+package jsonschema
+
+import (
+	"encoding"
+	"encoding/json"
+	"path"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const Draft = "https://json-schema.org/draft/2020-12/schema"
+
+type Schema struct {
+	Schema               string             `json:"$schema,omitempty"`
+	ID                   string             `json:"$id,omitempty"`
+	Ref                  string             `json:"$ref,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Const                any                `json:"const,omitempty"`
+	Enum                 []any              `json:"enum,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	PropertyNames        *Schema            `json:"propertyNames,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AnyOf                []*Schema          `json:"anyOf,omitempty"`
+	OneOf                []*Schema          `json:"oneOf,omitempty"`
+	Defs                 map[string]*Schema `json:"$defs,omitempty"`
+}
+
+// Enumer is implemented by string-like types that only accept a fixed set of
+// values, e.g. dto.TeamColor.
+type Enumer interface {
+	JSONSchemaEnum() []any
+}
+
+var (
+	enumerType       = reflect.TypeFor[Enumer]()
+	textMarshalType  = reflect.TypeFor[encoding.TextMarshaler]()
+	rawMessageType   = reflect.TypeFor[json.RawMessage]()
+	timeType         = reflect.TypeFor[time.Time]()
+	uuidType         = reflect.TypeFor[uuid.UUID]()
+	emptyInterface   = reflect.TypeFor[any]()
+	definitionPrefix = "#/$defs/"
+)
+
+// Reflector builds schemas from Go types following encoding/json rules.
+// Named structs and enums are collected in Defs and referenced by name.
+type Reflector struct {
+	Defs  map[string]*Schema
+	names map[reflect.Type]string
+}
+
+func NewReflector() *Reflector {
+	return &Reflector{Defs: make(map[string]*Schema), names: make(map[reflect.Type]string)}
+}
+
+func Ref(name string) *Schema {
+	return &Schema{Ref: definitionPrefix + name}
+}
+
+func (r *Reflector) Reflect(t reflect.Type) *Schema {
+	switch {
+	case t == nil || t == emptyInterface || t == rawMessageType:
+		return &Schema{}
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t == uuidType:
+		return &Schema{Type: "string", Format: "uuid"}
+	case t.Implements(enumerType) && t.Name() != "":
+		return r.define(t, func() *Schema {
+			values := reflect.Zero(t).Interface().(Enumer).JSONSchemaEnum()
+			return &Schema{Type: kindType(t.Kind()), Enum: values}
+		})
+	case t.Kind() != reflect.Pointer && t.Implements(textMarshalType):
+		return &Schema{Type: "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return &Schema{AnyOf: []*Schema{r.Reflect(t.Elem()), {Type: "null"}}}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: r.Reflect(t.Elem())}
+	case reflect.Map:
+		s := &Schema{Type: "object", AdditionalProperties: r.Reflect(t.Elem())}
+		if t.Key().Implements(enumerType) {
+			s.PropertyNames = r.Reflect(t.Key())
+		}
+		return s
+	case reflect.Struct:
+		if t.Name() == "" {
+			return r.structSchema(t)
+		}
+		return r.define(t, func() *Schema { return r.structSchema(t) })
+	case reflect.Interface:
+		return &Schema{}
+	default:
+		return &Schema{Type: kindType(t.Kind())}
+	}
+}
+
+func (r *Reflector) define(t reflect.Type, build func() *Schema) *Schema {
+	if name, ok := r.names[t]; ok {
+		return Ref(name)
+	}
+
+	name := t.Name()
+	if _, taken := r.Defs[name]; taken {
+		name = path.Base(t.PkgPath()) + "." + name
+	}
+
+	// Reserve the name before building so recursive types terminate.
+	r.names[t] = name
+	r.Defs[name] = &Schema{}
+	*r.Defs[name] = *build()
+
+	return Ref(name)
+}
+
+func (r *Reflector) structSchema(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	r.addFields(s, t)
+	return s
+}
+
+func (r *Reflector) addFields(s *Schema, t reflect.Type) {
+	for i := range t.NumField() {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				r.addFields(s, ft)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		s.Properties[name] = r.Reflect(f.Type)
+		if !strings.Contains(opts, "omitempty") && !strings.Contains(opts, "omitzero") {
+			s.Required = append(s.Required, name)
+		}
+	}
+}
+
+func kindType(k reflect.Kind) string {
+	switch k {
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.String:
+		return "string"
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,53 @@
+package jsonschema
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+type color string
+
+func (color) JSONSchemaEnum() []any { return []any{"red", "blue"} }
+
+type node struct {
+	ID       uuid.UUID         `json:"id"`
+	Color    color             `json:"color"`
+	Children []*node           `json:"children,omitempty"`
+	Scores   map[color]float64 `json:"scores"`
+	Hidden   string            `json:"-"`
+}
+
+func TestReflect(t *testing.T) {
+	r := NewReflector()
+
+	s := r.Reflect(reflect.TypeFor[node]())
+	if s.Ref != "#/$defs/node" {
+		t.Fatalf("expected ref to node; got %q", s.Ref)
+	}
+
+	def := r.Defs["node"]
+	if def == nil || def.Type != "object" {
+		t.Fatalf("expected node definition of type object; got %+v", def)
+	}
+	if _, ok := def.Properties["Hidden"]; ok {
+		t.Errorf("expected json:\"-\" field to be skipped")
+	}
+	if want := []string{"id", "color", "scores"}; !slices.Equal(def.Required, want) {
+		t.Errorf("expected required %v; got %v", want, def.Required)
+	}
+	if got := def.Properties["id"]; got.Type != "string" || got.Format != "uuid" {
+		t.Errorf("expected uuid string; got %+v", got)
+	}
+	if got := def.Properties["children"].Items.AnyOf[0].Ref; got != "#/$defs/node" {
+		t.Errorf("expected recursive ref; got %q", got)
+	}
+	if got := r.Defs["color"]; got == nil || len(got.Enum) != 2 {
+		t.Errorf("expected color enum definition; got %+v", got)
+	}
+	if got := def.Properties["scores"].PropertyNames; got == nil || got.Ref != "#/$defs/color" {
+		t.Errorf("expected enum property names; got %+v", got)
+	}
+}
@@ -0,0 +1,118 @@
+package printing
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+)
+
+// pdfWriter writes a minimal PDF 1.4 document: one Helvetica Bold font and
+// pages made of filled rectangles and text, which is all the sheets need.
+type pdfWriter struct {
+	buf     bytes.Buffer
+	offsets []int
+}
+
+// object starts the next numbered object and returns its number.
+func (p *pdfWriter) object() int {
+	p.offsets = append(p.offsets, p.buf.Len())
+	n := len(p.offsets)
+	fmt.Fprintf(&p.buf, "%d 0 obj\n", n)
+	return n
+}
+
+func (p *pdfWriter) endObject() {
+	p.buf.WriteString("endobj\n")
+}
+
+func pdfColor(hex string) string {
+	v, err := strconv.ParseUint(strings.TrimPrefix(hex, "#"), 16, 32)
+	if err != nil {
+		return "0 0 0"
+	}
+	return fmt.Sprintf("%.3f %.3f %.3f", float64(v>>16&0xff)/255, float64(v>>8&0xff)/255, float64(v&0xff)/255)
+}
+
+var winAnsi = encoding.ReplaceUnsupported(charmap.Windows1252.NewEncoder())
+
+// pdfString encodes s for the standard fonts as a literal string.
+func pdfString(s string) string {
+	encoded, err := winAnsi.String(s)
+	if err != nil {
+		encoded = "?"
+	}
+
+	r := strings.NewReplacer(`\`, `\\`, `(`, `\(`, `)`, `\)`)
+	return "(" + r.Replace(encoded) + ")"
+}
+
+// content returns the page's drawing operators, flipping the sheet's top
+// left origin to PDF's bottom left one.
+func (s *Sheet) content() []byte {
+	var b bytes.Buffer
+
+	for _, r := range s.Rects {
+		fmt.Fprintf(&b, "%s rg %s RG %.2f %.2f %.2f %.2f re B\n", pdfColor(r.Fill), pdfColor(r.Stroke), r.X, s.Height-r.Y-r.H, r.W, r.H)
+	}
+	for _, t := range s.Texts {
+		width := float64(len([]rune(t.Value))) * t.Size * avgGlyphWidth
+		fmt.Fprintf(&b, "BT /F1 %.2f Tf %s rg %.2f %.2f Td %s Tj ET\n", t.Size, pdfColor(t.Fill), t.X-width/2, s.Height-t.Y, pdfString(t.Value))
+	}
+
+	return b.Bytes()
+}
+
+// WritePDF renders the sheets as the pages of one PDF document.
+func WritePDF(w io.Writer, sheets ...*Sheet) error {
+	var p pdfWriter
+	p.buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// Objects 1 to 3 are the catalog, the page tree and the font. Every page
+	// then takes two objects: the page and its content stream.
+	const pagesObj = 2
+	kids := make([]string, len(sheets))
+	for i := range sheets {
+		kids[i] = fmt.Sprintf("%d 0 R", 4+2*i)
+	}
+
+	p.object()
+	fmt.Fprintf(&p.buf, "<< /Type /Catalog /Pages %d 0 R >>\n", pagesObj)
+	p.endObject()
+
+	p.object()
+	fmt.Fprintf(&p.buf, "<< /Type /Pages /Kids [%s] /Count %d >>\n", strings.Join(kids, " "), len(sheets))
+	p.endObject()
+
+	font := p.object()
+	p.buf.WriteString("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>\n")
+	p.endObject()
+
+	for _, s := range sheets {
+		page := p.object()
+		fmt.Fprintf(&p.buf, "<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %g %g] /Resources << /Font << /F1 %d 0 R >> >> /Contents %d 0 R >>\n",
+			pagesObj, s.Width, s.Height, font, page+1)
+		p.endObject()
+
+		content := s.content()
+		p.object()
+		fmt.Fprintf(&p.buf, "<< /Length %d >>\nstream\n", len(content))
+		p.buf.Write(content)
+		p.buf.WriteString("\nendstream\n")
+		p.endObject()
+	}
+
+	xref := p.buf.Len()
+	fmt.Fprintf(&p.buf, "xref\n0 %d\n0000000000 65535 f \n", len(p.offsets)+1)
+	for _, off := range p.offsets {
+		fmt.Fprintf(&p.buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&p.buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(p.offsets)+1, xref)
+
+	_, err := p.buf.WriteTo(w)
+	return err
+}
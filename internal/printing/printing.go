@@ -0,0 +1,157 @@
+// Package printing renders game boards and spymaster key cards for play on
+// paper, as SVG or as a PDF bundle. Both outputs are drawn from the same
+// sheet layout, so they look alike.
+package printing
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ninox14/gore-codenames/internal/database/dto"
+)
+
+type SheetKind string
+
+const (
+	SheetBoard SheetKind = "board"
+	SheetKey   SheetKind = "key"
+)
+
+// A4 landscape in points, also used as the SVG view box.
+const (
+	pageWidth  = 842.0
+	pageHeight = 595.0
+	margin     = 36.0
+	titleSize  = 20.0
+	// avgGlyphWidth approximates Helvetica Bold's width per point of font
+	// size, which is good enough to fit and centre words.
+	avgGlyphWidth = 0.62
+)
+
+var cardColors = map[dto.CardColor]string{
+	dto.CardColorRed:      "#c8322d",
+	dto.CardColorBlue:     "#2a62c9",
+	dto.CardColorNeutral:  "#e9dcbc",
+	dto.CardColorAssassin: "#222222",
+}
+
+const (
+	cardFill   = "#f6eedb"
+	cardStroke = "#8a7a5a"
+	inkColor   = "#1e1e1e"
+)
+
+type rect struct {
+	X, Y, W, H   float64
+	Fill, Stroke string
+}
+
+// text is drawn centred on X with its baseline at Y.
+type text struct {
+	X, Y  float64
+	Size  float64
+	Fill  string
+	Value string
+}
+
+// Sheet is one printable page. Coordinates start at the top left corner.
+type Sheet struct {
+	Width, Height float64
+	Rects         []rect
+	Texts         []text
+}
+
+// gridSize returns the columns and rows of the board, falling back to a
+// square grid for boards without a size.
+func gridSize(b *dto.Board) (int, int) {
+	if b.Size != nil && b.Size.X > 0 && b.Size.Y > 0 {
+		return b.Size.X, b.Size.Y
+	}
+
+	cols := 1
+	for cols*cols < len(b.CurrentBoard) {
+		cols++
+	}
+	return cols, (len(b.CurrentBoard) + cols - 1) / cols
+}
+
+// fitText returns the largest font size up to max at which value fits in
+// width.
+func fitText(value string, width, max float64) float64 {
+	n := float64(len([]rune(value)))
+	if n == 0 {
+		return max
+	}
+	return min(max, width/(n*avgGlyphWidth))
+}
+
+func newSheet(title string) *Sheet {
+	return &Sheet{
+		Width:  pageWidth,
+		Height: pageHeight,
+		Texts:  []text{{X: pageWidth / 2, Y: margin + titleSize, Size: titleSize, Fill: inkColor, Value: title}},
+	}
+}
+
+// BoardSheet lays out the word grid the operatives see.
+func BoardSheet(b *dto.Board) *Sheet {
+	s := newSheet("Codenames")
+	cols, rows := gridSize(b)
+
+	top := margin + titleSize*2
+	gap := 8.0
+	cellW := (pageWidth - 2*margin - gap*float64(cols-1)) / float64(cols)
+	cellH := (pageHeight - top - margin - gap*float64(rows-1)) / float64(rows)
+
+	for i, word := range b.CurrentBoard {
+		x := margin + float64(i%cols)*(cellW+gap)
+		y := top + float64(i/cols)*(cellH+gap)
+		s.Rects = append(s.Rects, rect{X: x, Y: y, W: cellW, H: cellH, Fill: cardFill, Stroke: cardStroke})
+
+		word = strings.ToUpper(word)
+		size := fitText(word, cellW*0.85, 20)
+		s.Texts = append(s.Texts, text{X: x + cellW/2, Y: y + cellH/2 + size/3, Size: size, Fill: inkColor, Value: word})
+	}
+
+	return s
+}
+
+// KeySheet lays out the spymaster key card. Its frame has the colour of the
+// team that starts, as on the physical cards.
+func KeySheet(b *dto.Board) *Sheet {
+	first := dto.TeamColorRed
+	if len(b.TurnOrder) > 0 {
+		first = b.TurnOrder[0]
+	}
+
+	s := newSheet(fmt.Sprintf("Spymaster key - %s starts", first))
+	cols, rows := gridSize(b)
+
+	top := margin + titleSize*2
+	side := min(pageWidth-2*margin, pageHeight-top-margin)
+	frame := 14.0
+	gap := 6.0
+	cell := (side - 2*frame - gap*float64(max(cols, rows)-1)) / float64(max(cols, rows))
+
+	x0 := (pageWidth - side) / 2
+	s.Rects = append(s.Rects, rect{X: x0, Y: top, W: side, H: side, Fill: cardColors[dto.CardColor(first)], Stroke: inkColor})
+
+	for i := range b.CurrentBoard {
+		x := x0 + frame + float64(i%cols)*(cell+gap)
+		y := top + frame + float64(i/cols)*(cell+gap)
+		s.Rects = append(s.Rects, rect{X: x, Y: y, W: cell, H: cell, Fill: cardColors[b.ColorOf(i)], Stroke: "#ffffff"})
+	}
+
+	return s
+}
+
+// Sheet returns the sheet of the given kind.
+func (k SheetKind) Sheet(b *dto.Board) (*Sheet, error) {
+	switch k {
+	case SheetBoard:
+		return BoardSheet(b), nil
+	case SheetKey:
+		return KeySheet(b), nil
+	}
+	return nil, fmt.Errorf("unknown sheet %q", k)
+}
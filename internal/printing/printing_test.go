@@ -0,0 +1,91 @@
+package printing
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/ninox14/gore-codenames/internal/database/dto"
+)
+
+func testBoard() *dto.Board {
+	words := make([]string, 25)
+	for i := range words {
+		words[i] = "word" + strconv.Itoa(i)
+	}
+	words[3] = "R&D"
+
+	return &dto.Board{
+		Size:           &dto.BoardSize{X: 5, Y: 5},
+		CurrentBoard:   words,
+		AssassinIndexs: []int{0},
+		TurnOrder:      []dto.TeamColor{dto.TeamColorBlue, dto.TeamColorRed},
+		WordsByTeam:    map[dto.TeamColor][]int{dto.TeamColorBlue: {1, 2}, dto.TeamColorRed: {3}},
+	}
+}
+
+func TestSVGIsWellFormed(t *testing.T) {
+	for _, kind := range []SheetKind{SheetBoard, SheetKey} {
+		sheet, err := kind.Sheet(testBoard())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var buf bytes.Buffer
+		if err := sheet.WriteSVG(&buf); err != nil {
+			t.Fatal(err)
+		}
+
+		dec := xml.NewDecoder(&buf)
+		for {
+			_, err := dec.Token()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("%s sheet is not valid XML: %v", kind, err)
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	BoardSheet(testBoard()).WriteSVG(&buf)
+	if !strings.Contains(buf.String(), "R&amp;D") {
+		t.Errorf("expected escaped word in board SVG")
+	}
+}
+
+func TestPDFCrossReferenceOffsets(t *testing.T) {
+	b := testBoard()
+
+	var buf bytes.Buffer
+	if err := WritePDF(&buf, BoardSheet(b), KeySheet(b)); err != nil {
+		t.Fatal(err)
+	}
+	doc := buf.Bytes()
+
+	if !bytes.Contains(doc, []byte("/Count 2")) {
+		t.Errorf("expected a two page document")
+	}
+
+	m := regexp.MustCompile(`startxref\n(\d+)`).FindSubmatch(doc)
+	if m == nil {
+		t.Fatal("missing startxref")
+	}
+	xref, _ := strconv.Atoi(string(m[1]))
+	if !bytes.HasPrefix(doc[xref:], []byte("xref")) {
+		t.Fatalf("startxref does not point at the xref table")
+	}
+
+	offsets := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllSubmatch(doc[xref:], -1)
+	for i, off := range offsets {
+		n, _ := strconv.Atoi(string(off[1]))
+		if want := strconv.Itoa(i+1) + " 0 obj"; !bytes.HasPrefix(doc[n:], []byte(want)) {
+			t.Errorf("offset of object %d points at %q", i+1, doc[n:n+10])
+		}
+	}
+}
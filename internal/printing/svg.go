@@ -0,0 +1,31 @@
+package printing
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+)
+
+// WriteSVG renders the sheet as a standalone SVG document.
+func (s *Sheet) WriteSVG(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintf(bw, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %g %g" width="%gpt" height="%gpt">`+"\n", s.Width, s.Height, s.Width, s.Height)
+	fmt.Fprintf(bw, `<rect width="100%%" height="100%%" fill="#ffffff"/>`+"\n")
+
+	for _, r := range s.Rects {
+		fmt.Fprintf(bw, `<rect x="%.2f" y="%.2f" width="%.2f" height="%.2f" rx="4" fill="%s" stroke="%s"/>`+"\n", r.X, r.Y, r.W, r.H, r.Fill, r.Stroke)
+	}
+
+	for _, t := range s.Texts {
+		fmt.Fprintf(bw, `<text x="%.2f" y="%.2f" font-family="Helvetica, Arial, sans-serif" font-weight="bold" font-size="%.2f" text-anchor="middle" fill="%s">`, t.X, t.Y, t.Size, t.Fill)
+		if err := xml.EscapeText(bw, []byte(t.Value)); err != nil {
+			return err
+		}
+		bw.WriteString("</text>\n")
+	}
+
+	bw.WriteString("</svg>\n")
+	return bw.Flush()
+}
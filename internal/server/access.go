@@ -0,0 +1,147 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/ninox14/gore-codenames/internal/database/sqlc"
+	"github.com/pascaldekloe/jwt"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	DefaultInviteExpiry = 24 * time.Hour
+	MaxInviteExpiry     = 7 * 24 * time.Hour
+	MinGamePasswordLen  = 4
+	// MaxGamePasswordLen is bcrypt's input limit.
+	MaxGamePasswordLen = 72
+)
+
+var (
+	ErrPasswordRequired   = errors.New("this game requires a password")
+	ErrWrongPassword      = errors.New("wrong game password")
+	ErrInviteRequired     = errors.New("this game is invite only")
+	ErrInvalidInviteToken = errors.New("invalid or expired invite")
+	ErrNotAdmitted        = errors.New("connection was not admitted to this game")
+)
+
+// GameCredentials are what a player presents when connecting to a game.
+type GameCredentials struct {
+	Password string
+	Invite   string
+}
+
+func hashGamePassword(password string) (pgtype.Text, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return pgtype.Text{}, err
+	}
+	return pgtype.Text{String: string(hash), Valid: true}, nil
+}
+
+// inviteAudience keeps invite tokens from being accepted as any other kind of
+// token.
+func (s *Server) inviteAudience() string {
+	return s.config.baseURL + "/invite"
+}
+
+func (s *Server) signInviteToken(gameID uuid.UUID, ttl time.Duration) ([]byte, time.Time, error) {
+	var claims jwt.Claims
+	claims.Subject = gameID.String()
+
+	// Truncated rather than rounded, so an invite is usable right away.
+	now := time.Now().Truncate(time.Second)
+	expiry := time.Now().Add(ttl)
+	claims.Issued = jwt.NewNumericTime(now)
+	claims.NotBefore = jwt.NewNumericTime(now)
+	claims.Expires = jwt.NewNumericTime(expiry.Round(time.Second))
+
+	claims.Issuer = s.config.baseURL
+	claims.Audiences = []string{s.inviteAudience()}
+
+	token, err := claims.HMACSign(jwt.HS256, []byte(s.config.jwt.secretKey))
+	return token, expiry, err
+}
+
+func (s *Server) checkInviteToken(token string, gameID uuid.UUID) error {
+	claims, err := jwt.HMACCheck([]byte(token), []byte(s.config.jwt.secretKey))
+	if err != nil {
+		return ErrInvalidInviteToken
+	}
+
+	if !claims.Valid(time.Now()) ||
+		claims.Issuer != s.config.baseURL ||
+		!claims.AcceptAudience(s.inviteAudience()) ||
+		claims.Subject != gameID.String() {
+		return ErrInvalidInviteToken
+	}
+
+	return nil
+}
+
+// checkGameAccess decides whether the user may connect to the game. The host
+// and players who already joined are let back in without credentials, so a
+// dropped connection does not need a new invite.
+func (s *Server) checkGameAccess(ctx context.Context, game sqlc.Game, userID uuid.UUID, creds GameCredentials) error {
+	if game.AccessPolicy == sqlc.GameAccessOpen || game.HostID == userID {
+		return nil
+	}
+
+	gs, err := s.gh.GetGameState(ctx, game.ID)
+	if err == nil && isInGame(&gs, userID) {
+		return nil
+	}
+	// Ended games only live in Postgres.
+	if errors.Is(err, ErrGameStateMissing) && game.GameState != nil && isInGame(game.GameState, userID) {
+		return nil
+	}
+
+	switch game.AccessPolicy {
+	case sqlc.GameAccessPassword:
+		if creds.Password == "" {
+			return ErrPasswordRequired
+		}
+		err := bcrypt.CompareHashAndPassword([]byte(game.PasswordHash.String), []byte(creds.Password))
+		if err != nil {
+			return ErrWrongPassword
+		}
+		return nil
+	case sqlc.GameAccessInvite:
+		if creds.Invite == "" {
+			return ErrInviteRequired
+		}
+		return s.checkInviteToken(creds.Invite, game.ID)
+	default:
+		return ErrInviteRequired
+	}
+}
+
+// checkViewAccess decides whether a request may watch a game or read its
+// history without a socket. Open games are public. Other games take a
+// spectator token, which only members get, or a user who is the host or a
+// member, without asking for the password or invite again.
+func (s *Server) checkViewAccess(r *http.Request, game sqlc.Game) error {
+	if token := r.URL.Query().Get("spectator_token"); token != "" {
+		return s.checkSpectatorToken(token, game.ID)
+	}
+	if game.AccessPolicy == sqlc.GameAccessOpen {
+		return nil
+	}
+
+	// Anonymous requests are checked as nobody and get told what is missing.
+	user, _ := contextGetAuthenticatedUser(r)
+	return s.checkGameAccess(r.Context(), game, user.ID, GameCredentials{})
+}
+
+// viewAccessDenied writes the response for a checkViewAccess error.
+func (s *Server) viewAccessDenied(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, ErrInvalidSpectatorToken) {
+		s.errorMessage(w, r, http.StatusUnauthorized, err.Error(), nil)
+		return
+	}
+	s.accessDenied(w, r, err)
+}
@@ -0,0 +1,75 @@
+package server
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestInviteTokens(t *testing.T) {
+	s := &Server{}
+	s.config.baseURL = "http://localhost:8080"
+	s.config.jwt.secretKey = "test-secret"
+
+	gameID := uuid.New()
+	token, _, err := s.signInviteToken(gameID, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.checkInviteToken(string(token), gameID); err != nil {
+		t.Errorf("expected invite to be accepted: %v", err)
+	}
+	if err := s.checkInviteToken(string(token), uuid.New()); err != ErrInvalidInviteToken {
+		t.Errorf("expected invite for another game to be rejected, got %v", err)
+	}
+
+	spectator, _, err := s.signSpectatorToken(gameID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.checkInviteToken(string(spectator), gameID); err != ErrInvalidInviteToken {
+		t.Errorf("expected spectator token to be rejected as an invite, got %v", err)
+	}
+
+	expired, _, err := s.signInviteToken(gameID, -time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.checkInviteToken(string(expired), gameID); err != ErrInvalidInviteToken {
+		t.Errorf("expected expired invite to be rejected, got %v", err)
+	}
+}
+
+func TestRedactedURL(t *testing.T) {
+	u, _ := url.Parse("/ws?game=abc&password=hunter2&invite=xyz")
+	logged := redactedURL(u)
+	if strings.Contains(logged, "hunter2") || strings.Contains(logged, "xyz") {
+		t.Errorf("expected credentials to be redacted; got %s", logged)
+	}
+	if !strings.Contains(logged, "game=abc") {
+		t.Errorf("expected other parameters to be kept; got %s", logged)
+	}
+}
+
+func TestSpectatorTokenUsableRightAway(t *testing.T) {
+	s := &Server{}
+	s.config.baseURL = "http://localhost:8080"
+	s.config.jwt.secretKey = "test-secret"
+	gameID := uuid.New()
+
+	// Spread over a second, so some tokens are signed late in it.
+	for range 11 {
+		token, _, err := s.signSpectatorToken(gameID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := s.checkSpectatorToken(string(token), gameID); err != nil {
+			t.Fatalf("expected a fresh spectator token to be accepted: %v", err)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
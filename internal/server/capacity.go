@@ -0,0 +1,131 @@
+package server
+
+import (
+	"errors"
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/ninox14/gore-codenames/internal/database/dto"
+)
+
+const (
+	DefaultMaxPlayersPerTeam = 8
+	DefaultMaxSpectators     = 50
+	// MaxTeamSize and MaxSpectatorSeats bound what hosts can configure.
+	MaxTeamSize       = 16
+	MaxSpectatorSeats = 200
+)
+
+var (
+	ErrTeamFull       = errors.New("the team is full, you can keep watching as a spectator")
+	ErrSpectatorsFull = errors.New("all spectator seats are taken")
+)
+
+func teamFull(gs *dto.GameState, color dto.TeamColor) bool {
+	limit := gs.Settings.MaxPlayersPerTeam
+	return limit > 0 && len(gs.Teams[color].Players) >= limit
+}
+
+func spectatorsFull(gs *dto.GameState) bool {
+	limit := gs.Settings.MaxSpectators
+	return limit > 0 && len(gs.Spectators) >= limit
+}
+
+// promoteWaitlist moves waiting players into spectator seats freed up by the
+// action being applied.
+func promoteWaitlist(gs *dto.GameState) {
+	for len(gs.Waitlist) > 0 && !spectatorsFull(gs) {
+		gs.Spectators = append(gs.Spectators, gs.Waitlist[0])
+		gs.Waitlist = gs.Waitlist[1:]
+	}
+}
+
+// stateRecipients lists who gets full game states. Waiting players are left
+// out, which is the point of capping spectators. Nil means everyone.
+func stateRecipients(gs *dto.GameState) []uuid.UUID {
+	if len(gs.Waitlist) == 0 {
+		return nil
+	}
+
+	recipients := make([]uuid.UUID, 0, len(gs.Spectators))
+	for _, p := range gs.Spectators {
+		recipients = append(recipients, p.ID)
+	}
+	for _, team := range gs.Teams {
+		for _, p := range team.Players {
+			recipients = append(recipients, p.ID)
+		}
+	}
+	return recipients
+}
+
+// WaitlistData is sent to waiting players instead of the game state.
+type WaitlistData struct {
+	Waitlist      []dto.GameStatePlayer `json:"waitlist"`
+	MaxSpectators int                   `json:"max_spectators"`
+}
+
+func waitlistIDs(gs *dto.GameState) []uuid.UUID {
+	ids := make([]uuid.UUID, len(gs.Waitlist))
+	for i, p := range gs.Waitlist {
+		ids[i] = p.ID
+	}
+	return ids
+}
+
+// sortedTeams returns the team colours in a fixed order, so actions iterating
+// teams stay deterministic.
+func sortedTeams(gs *dto.GameState) []dto.TeamColor {
+	colors := make([]dto.TeamColor, 0, len(gs.Teams))
+	for color := range gs.Teams {
+		colors = append(colors, color)
+	}
+	slices.Sort(colors)
+	return colors
+}
+
+// BalanceTeamsData evens out team sizes by moving the latest joined
+// operatives from bigger teams to smaller ones. Host only.
+type BalanceTeamsData struct{}
+
+func (BalanceTeamsData) Apply(gs *dto.GameState, actor dto.GameStatePlayer) error {
+	if gs.HostID != actor.ID {
+		return ErrNotHost
+	}
+	if gs.Phase != dto.GamePhaseLobby {
+		return ErrNotInLobby
+	}
+
+	colors := sortedTeams(gs)
+	moved := false
+	for {
+		largest, smallest := colors[0], colors[0]
+		for _, c := range colors {
+			if len(gs.Teams[c].Players) > len(gs.Teams[largest].Players) {
+				largest = c
+			}
+			if len(gs.Teams[c].Players) < len(gs.Teams[smallest].Players) {
+				smallest = c
+			}
+		}
+		from, to := gs.Teams[largest], gs.Teams[smallest]
+		if len(from.Players)-len(to.Players) <= 1 {
+			break
+		}
+
+		// A team with two more players than another has an operative.
+		idx := len(from.Players) - 1
+		if from.CaptainID != nil && from.Players[idx].ID == *from.CaptainID {
+			idx--
+		}
+		player := from.Players[idx]
+		from.Players = slices.Delete(from.Players, idx, idx+1)
+		to.Players = append(to.Players, player)
+		moved = true
+	}
+
+	if !moved {
+		return ErrNoChange
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package server
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/ninox14/gore-codenames/internal/database/dto"
+)
+
+func TestSeatLimitsAndWaitlist(t *testing.T) {
+	gs, _ := newTestGame(t)
+	gs.Phase = dto.GamePhaseLobby
+	gs.Settings.MaxPlayersPerTeam = 2
+	gs.Settings.MaxSpectators = 1
+
+	first := dto.GameStatePlayer{ID: uuid.New(), Name: "first"}
+	second := dto.GameStatePlayer{ID: uuid.New(), Name: "second"}
+	for _, p := range []dto.GameStatePlayer{first, second} {
+		if err := (JoinAction{}).Apply(gs, p); err != nil {
+			t.Fatalf("join: %v", err)
+		}
+	}
+	if !gs.IsSpectator(first.ID) || !gs.IsWaitlisted(second.ID) {
+		t.Fatalf("expected the second player to wait for a spectator seat")
+	}
+
+	if err := (ChangeTeamData{Destination: TeamRedPath}).Apply(gs, first); !errors.Is(err, ErrTeamFull) {
+		t.Fatalf("expected full team to be rejected; got %v", err)
+	}
+
+	gs.Settings.MaxPlayersPerTeam = 3
+	if err := (ChangeTeamData{Destination: TeamRedPath}).Apply(gs, first); err != nil {
+		t.Fatalf("change team: %v", err)
+	}
+	if !gs.IsSpectator(second.ID) || len(gs.Waitlist) != 0 {
+		t.Errorf("expected the waiting player to take the freed spectator seat")
+	}
+}
+
+func TestBalanceTeams(t *testing.T) {
+	gs, players := newTestGame(t)
+	gs.Phase = dto.GamePhaseLobby
+	host := players[0]
+
+	for range 3 {
+		p := dto.GameStatePlayer{ID: uuid.New(), Name: "late"}
+		(JoinAction{}).Apply(gs, p)
+		if err := (ChangeTeamData{Destination: TeamRedPath}).Apply(gs, p); err != nil {
+			t.Fatalf("change team: %v", err)
+		}
+	}
+
+	if err := (BalanceTeamsData{}).Apply(gs, players[1]); !errors.Is(err, ErrNotHost) {
+		t.Fatalf("expected balance by non-host to be rejected; got %v", err)
+	}
+	if err := (BalanceTeamsData{}).Apply(gs, host); err != nil {
+		t.Fatalf("balance: %v", err)
+	}
+
+	red, blue := gs.Teams[dto.TeamColorRed], gs.Teams[dto.TeamColorBlue]
+	if len(red.Players) != 4 || len(blue.Players) != 3 {
+		t.Errorf("expected teams of 4 and 3; got %d and %d", len(red.Players), len(blue.Players))
+	}
+	if red.CaptainID == nil || *red.CaptainID != host.ID {
+		t.Errorf("expected the red spymaster to stay")
+	}
+	if err := (BalanceTeamsData{}).Apply(gs, host); !errors.Is(err, ErrNoChange) {
+		t.Errorf("expected balanced teams to be left alone; got %v", err)
+	}
+}
+
+func TestLeavingFreesSpectatorSeat(t *testing.T) {
+	gs, players := newTestGame(t)
+	gs.Settings.MaxSpectators = 1
+
+	watcher := dto.GameStatePlayer{ID: uuid.New(), Name: "watcher"}
+	waiting := dto.GameStatePlayer{ID: uuid.New(), Name: "waiting"}
+	(JoinAction{}).Apply(gs, watcher)
+	(JoinAction{}).Apply(gs, waiting)
+
+	if err := (LeaveAction{}).Apply(gs, players[1]); !errors.Is(err, ErrNoChange) {
+		t.Fatalf("expected seated players to keep their seat; got %v", err)
+	}
+	if err := (LeaveAction{}).Apply(gs, watcher); err != nil {
+		t.Fatalf("leave: %v", err)
+	}
+	if isInGame(gs, watcher.ID) || !gs.IsSpectator(waiting.ID) {
+		t.Errorf("expected the waiting player to take the freed seat")
+	}
+}
+
+func TestZeroSeatLimitIsUncapped(t *testing.T) {
+	gs, _ := newTestGame(t)
+	gs.Settings.MaxPlayersPerTeam = 0
+	gs.Settings.MaxSpectators = 0
+	for range MaxSpectatorSeats + 1 {
+		gs.Spectators = append(gs.Spectators, dto.GameStatePlayer{ID: uuid.New()})
+	}
+
+	if teamFull(gs, dto.TeamColorRed) || spectatorsFull(gs) {
+		t.Errorf("expected a zero limit to leave the seats uncapped")
+	}
+}
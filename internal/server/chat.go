@@ -0,0 +1,212 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/ninox14/gore-codenames/internal/database/dto"
+)
+
+const (
+	MaxChatMessageRunes = 500
+	ChatHistorySize     = 100
+)
+
+type ChatChannel string
+
+const (
+	ChatChannelAll        ChatChannel = "all"
+	ChatChannelTeam       ChatChannel = "team"
+	ChatChannelSpectators ChatChannel = "spectators"
+)
+
+func (ChatChannel) JSONSchemaEnum() []any {
+	return []any{ChatChannelAll, ChatChannelTeam, ChatChannelSpectators}
+}
+
+type ChatMessageData struct {
+	Channel ChatChannel `json:"channel"`
+	Text    string      `json:"text"`
+}
+
+type ChatEntry struct {
+	ID      uuid.UUID           `json:"id"`
+	Channel ChatChannel         `json:"channel"`
+	Team    *dto.TeamColor      `json:"team,omitempty"`
+	Sender  dto.GameStatePlayer `json:"sender"`
+	Text    string              `json:"text"`
+	SentAt  time.Time           `json:"sent_at"`
+}
+
+type ChatHistoryData struct {
+	Messages []ChatEntry `json:"messages"`
+}
+
+var (
+	ErrChatEmpty          = errors.New("message must not be empty")
+	ErrChatTooLong        = fmt.Errorf("message must not be longer than %d characters", MaxChatMessageRunes)
+	ErrChatNotSeated      = errors.New("only players in a team can use team chat")
+	ErrChatNotSpectator   = errors.New("only spectators can use spectator chat")
+	ErrChatSpymasterMuted = errors.New("spymasters cannot use team chat during a turn")
+	ErrChatUnknownChannel = errors.New("unknown chat channel")
+)
+
+func GetRedisChatKey(gameID uuid.UUID) string {
+	return fmt.Sprintf("game:%s:chat", gameID)
+}
+
+func (g *Game) GetRedisChatKey() string {
+	return GetRedisChatKey(g.ID)
+}
+
+// newChatEntry validates a message against the sender's seat in the game
+// state and builds the entry to store and deliver.
+func newChatEntry(gs *dto.GameState, sender dto.GameStatePlayer, data ChatMessageData) (*ChatEntry, error) {
+	text := strings.TrimSpace(data.Text)
+	if text == "" {
+		return nil, ErrChatEmpty
+	}
+	if utf8.RuneCountInString(text) > MaxChatMessageRunes {
+		return nil, ErrChatTooLong
+	}
+
+	entry := &ChatEntry{
+		ID:      uuid.New(),
+		Channel: data.Channel,
+		Sender:  sender,
+		Text:    text,
+		SentAt:  time.Now().UTC(),
+	}
+
+	switch data.Channel {
+	case ChatChannelAll:
+	case ChatChannelTeam:
+		team, ok := gs.TeamOf(sender.ID)
+		if !ok {
+			return nil, ErrChatNotSeated
+		}
+		if gs.Settings.MuteSpymastersInTeamChat && gs.Turn != nil && gs.IsCaptain(sender.ID) {
+			return nil, ErrChatSpymasterMuted
+		}
+		entry.Team = &team
+	case ChatChannelSpectators:
+		if !gs.IsSpectator(sender.ID) {
+			return nil, ErrChatNotSpectator
+		}
+	default:
+		return nil, ErrChatUnknownChannel
+	}
+
+	return entry, nil
+}
+
+// canSee reports whether the player can read the entry given their current
+// seat. Players keep seeing "all" messages regardless of where they sit.
+func (e *ChatEntry) canSee(gs *dto.GameState, playerID uuid.UUID) bool {
+	switch e.Channel {
+	case ChatChannelAll:
+		return true
+	case ChatChannelTeam:
+		team, ok := gs.TeamOf(playerID)
+		return ok && e.Team != nil && team == *e.Team
+	case ChatChannelSpectators:
+		return gs.IsSpectator(playerID)
+	default:
+		return false
+	}
+}
+
+// audience lists the players who can read the entry, or nil for everyone.
+func (e *ChatEntry) audience(gs *dto.GameState) []uuid.UUID {
+	if e.Channel == ChatChannelAll {
+		return nil
+	}
+
+	recipients := make([]uuid.UUID, 0)
+	for _, p := range gs.Spectators {
+		if e.canSee(gs, p.ID) {
+			recipients = append(recipients, p.ID)
+		}
+	}
+	for _, team := range gs.Teams {
+		for _, p := range team.Players {
+			if e.canSee(gs, p.ID) {
+				recipients = append(recipients, p.ID)
+			}
+		}
+	}
+	return recipients
+}
+
+func (g *Game) SendChatMessage(ctx context.Context, playerID uuid.UUID, data ChatMessageData) {
+	player := g.GetGameHubPlayer(playerID)
+	if player == nil {
+		return
+	}
+
+	gs, err := g.GetGameStateFromRedis(ctx)
+	if err != nil {
+		writeErrorMessage(ctx, player.Client, "Could not send chat message", err)
+		return
+	}
+
+	entry, err := newChatEntry(&gs, GameHubPlayerToGameStatePlayer(player), data)
+	if err != nil {
+		writeErrorMessage(ctx, player.Client, "Could not send chat message", err)
+		return
+	}
+
+	js, err := json.Marshal(entry)
+	if err != nil {
+		writeErrorMessage(ctx, player.Client, "Could not send chat message", err)
+		return
+	}
+
+	key := g.GetRedisChatKey()
+	pipe := g.hub.rdb.TxPipeline()
+	pipe.RPush(ctx, key, js)
+	pipe.LTrim(ctx, key, -ChatHistorySize, -1)
+	g.hub.touchGame(ctx, pipe, g.ID)
+	if _, err := pipe.Exec(ctx); err != nil {
+		writeErrorMessage(ctx, player.Client, "Could not store chat message", err)
+		return
+	}
+
+	g.broadcastTo(ctx, Message{Type: MsgChatMessage, Data: entry}, entry.audience(&gs))
+	if entry.Channel == ChatChannelAll {
+		g.publishSpectatorEvent(ctx, MsgChatMessage, entry)
+	}
+}
+
+// sendChatHistory sends the stored messages the player is allowed to read.
+func (g *Game) sendChatHistory(ctx context.Context, player *Player) {
+	raw, err := g.hub.rdb.LRange(ctx, g.GetRedisChatKey(), 0, -1).Result()
+	if err != nil {
+		g.hub.logger.Error("Could not load chat history", "gameId", g.ID, "err", err)
+		return
+	}
+
+	gs, err := g.GetGameStateFromRedis(ctx)
+	if err != nil {
+		g.hub.logger.Error("Could not load chat history", "gameId", g.ID, "err", err)
+		return
+	}
+	history := ChatHistoryData{Messages: make([]ChatEntry, 0, len(raw))}
+	for _, item := range raw {
+		var entry ChatEntry
+		if err := json.Unmarshal([]byte(item), &entry); err != nil {
+			continue
+		}
+		if entry.canSee(&gs, player.ID) {
+			history.Messages = append(history.Messages, entry)
+		}
+	}
+
+	player.send(ctx, Message{Type: MsgChatHistory, Data: history})
+}
@@ -0,0 +1,128 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strconv"
+
+	"github.com/coder/websocket"
+	"github.com/google/uuid"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// Codec serializes protocol messages for one websocket connection. The codec
+// is picked per connection from the negotiated subprotocol.
+type Codec interface {
+	Name() string
+	FrameType() websocket.MessageType
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+var (
+	JSONCodec    Codec = jsonCodec{}
+	MsgpackCodec Codec = msgpackCodec{}
+)
+
+// codecs lists the encodings a client can ask for, in server preference order.
+var codecs = []Codec{JSONCodec, MsgpackCodec}
+
+func lookupCodec(name string) (Codec, bool) {
+	for _, c := range codecs {
+		if c.Name() == name {
+			return c, true
+		}
+	}
+	return nil, false
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Name() string                       { return "json" }
+func (jsonCodec) FrameType() websocket.MessageType   { return websocket.MessageText }
+func (jsonCodec) Marshal(v any) ([]byte, error)      { return json.Marshal(v) }
+func (jsonCodec) Unmarshal(data []byte, v any) error { return json.Unmarshal(data, v) }
+
+// msgpackCodec mirrors the JSON encoding: values go through their JSON form
+// first, so field names, omitted fields and custom marshalers are identical
+// in both codecs and the generated protocol schema describes either one.
+type msgpackCodec struct{}
+
+func (msgpackCodec) Name() string                     { return "msgpack" }
+func (msgpackCodec) FrameType() websocket.MessageType { return websocket.MessageBinary }
+
+func (msgpackCodec) Marshal(v any) ([]byte, error) {
+	js, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(js))
+	dec.UseNumber()
+
+	var generic any
+	if err := dec.Decode(&generic); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	enc := msgpack.NewEncoder(&buf)
+	enc.UseCompactInts(true)
+	if err := enc.Encode(compactNumbers(generic)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (msgpackCodec) Unmarshal(data []byte, v any) error {
+	var generic any
+	if err := msgpack.Unmarshal(data, &generic); err != nil {
+		return err
+	}
+
+	js, err := json.Marshal(generic)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(js, v)
+}
+
+// compactNumbers turns json.Number values into integers where possible so
+// MessagePack clients receive ints instead of floats.
+func compactNumbers(v any) any {
+	switch v := v.(type) {
+	case json.Number:
+		if i, err := strconv.ParseInt(string(v), 10, 64); err == nil {
+			return i
+		}
+		f, _ := v.Float64()
+		return f
+	case map[string]any:
+		for k, item := range v {
+			v[k] = compactNumbers(item)
+		}
+	case []any:
+		for i, item := range v {
+			v[i] = compactNumbers(item)
+		}
+	}
+	return v
+}
+
+// Client is a websocket connection together with its negotiated protocol.
+type Client struct {
+	Conn     *websocket.Conn
+	Protocol wsProtocol
+	// GameID is the game the connection passed the access policy for.
+	GameID uuid.UUID
+}
+
+func (c *Client) Send(ctx context.Context, msg Message) error {
+	data, err := c.Protocol.Codec.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	return c.Conn.Write(ctx, c.Protocol.Codec.FrameType(), data)
+}
@@ -0,0 +1,74 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+func TestMsgpackCodecRoundTrip(t *testing.T) {
+	gameID := uuid.New()
+	in := Message{
+		Type:   MsgChangeTeam,
+		Data:   ChangeTeamData{Destination: TeamRedPath},
+		GameID: &gameID,
+	}
+
+	frame, err := MsgpackCodec.Marshal(in)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+
+	var generic map[string]any
+	if err := msgpack.Unmarshal(frame, &generic); err != nil {
+		t.Fatalf("frame is not msgpack: %v", err)
+	}
+	if generic["game_id"] != gameID.String() {
+		t.Errorf("expected game_id encoded as string; got %#v", generic["game_id"])
+	}
+
+	var out Message
+	if err := MsgpackCodec.Unmarshal(frame, &out); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if out.Type != in.Type || *out.GameID != gameID || out.Data != in.Data {
+		t.Errorf("expected %+v; got %+v", in, out)
+	}
+}
+
+func TestMsgpackCodecIntegers(t *testing.T) {
+	frame, err := MsgpackCodec.Marshal(HelloData{ProtocolVersion: 1, SupportedVersions: []int{1}})
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+
+	var generic map[string]any
+	if err := msgpack.Unmarshal(frame, &generic); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if _, ok := generic["protocol_version"].(int8); !ok {
+		t.Errorf("expected compact integer; got %T", generic["protocol_version"])
+	}
+}
+
+func TestNegotiateSubprotocol(t *testing.T) {
+	tests := []struct {
+		token string
+		codec Codec
+		ok    bool
+	}{
+		{"codenames.v1", JSONCodec, true},
+		{"codenames.v1.json", JSONCodec, true},
+		{"codenames.v1.msgpack", MsgpackCodec, true},
+		{"codenames.v1.xml", nil, false},
+		{"codenames.v99", nil, false},
+	}
+
+	for _, tt := range tests {
+		p, ok := parseSubprotocol(tt.token)
+		if ok != tt.ok || (ok && p.Codec != tt.codec) {
+			t.Errorf("%s: expected %v %v; got %v %v", tt.token, tt.codec, tt.ok, p.Codec, ok)
+		}
+	}
+}
@@ -17,7 +17,7 @@ func (s *Server) reportServerError(r *http.Request, err error) {
 	var (
 		message = err.Error()
 		method  = r.Method
-		url     = r.URL.String()
+		url     = redactedURL(r.URL)
 		trace   = string(debug.Stack())
 	)
 
@@ -52,6 +52,15 @@ func (s *Server) methodNotAllowed(w http.ResponseWriter, r *http.Request) {
 	s.errorMessage(w, r, http.StatusMethodNotAllowed, message, nil)
 }
 
+func (s *Server) notPermitted(w http.ResponseWriter, r *http.Request) {
+	message := "Your user account doesn't have the necessary permissions to access this resource"
+	s.errorMessage(w, r, http.StatusForbidden, message, nil)
+}
+
+func (s *Server) accessDenied(w http.ResponseWriter, r *http.Request, err error) {
+	s.errorMessage(w, r, http.StatusForbidden, err.Error(), nil)
+}
+
 func (s *Server) badRequest(w http.ResponseWriter, r *http.Request, err error) {
 	s.errorMessage(w, r, http.StatusBadRequest, err.Error(), nil)
 }
@@ -0,0 +1,318 @@
+package server
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"expvar"
+	"fmt"
+	"reflect"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/ninox14/gore-codenames/internal/database/dto"
+	"github.com/ninox14/gore-codenames/internal/database/sqlc"
+)
+
+// EventGameCreated is the first event of every game. Its payload is the
+// initial game state the other events are folded into.
+const EventGameCreated = "game_created"
+
+var ErrInvalidEventLog = errors.New("invalid game event log")
+
+const (
+	eventWriteAttempts = 3
+	eventWriteBackoff  = 100 * time.Millisecond
+	// pendingEventsBatch is how many queued events the persister writes per
+	// tick.
+	pendingEventsBatch = 100
+)
+
+// redisPendingEventsKey lists events that could not be written to Postgres
+// yet. It is not a game key, so it outlives archived games.
+const redisPendingEventsKey = "games:pending_events"
+
+// eventMetrics counts written and failed event log entries, served on
+// /debug/vars.
+var eventMetrics = expvar.NewMap("game_events")
+
+// actionType returns the client message type an action is sent as.
+func actionType(action GameAction) (MessageType, error) {
+	switch action.(type) {
+	case JoinAction:
+		return MsgJoinGame, nil
+	case LeaveAction:
+		return MsgLeaveGame, nil
+	}
+
+	for _, spec := range messageRegistry[ClientMessage] {
+		if spec.Payload != nil && spec.Payload == reflect.TypeOf(action) {
+			return spec.Type, nil
+		}
+	}
+	return "", fmt.Errorf("no message type for action %T", action)
+}
+
+// decodeAction is the inverse of actionType, used when replaying events.
+func decodeAction(t MessageType, payload []byte) (GameAction, error) {
+	switch t {
+	case MsgJoinGame:
+		return JoinAction{}, nil
+	case MsgLeaveGame:
+		return LeaveAction{}, nil
+	}
+
+	spec, ok := LookupMessage(ClientMessage, t)
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownMessageType, t)
+	}
+	data, err := spec.decode(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	action, ok := data.(GameAction)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a game action", t)
+	}
+	return action, nil
+}
+
+func newCreatedEvent(gameID uuid.UUID, host sqlc.User, gs *dto.GameState) (sqlc.CreateGameEventParams, error) {
+	payload, err := json.Marshal(gs)
+	if err != nil {
+		return sqlc.CreateGameEventParams{}, err
+	}
+
+	return sqlc.CreateGameEventParams{
+		GameID:    gameID,
+		Seq:       gs.Version,
+		Type:      EventGameCreated,
+		ActorID:   host.ID,
+		ActorName: host.Name,
+		Payload:   payload,
+	}, nil
+}
+
+// newActionEvent records an action that produced the given state version.
+func newActionEvent(gameID uuid.UUID, version int64, actor dto.GameStatePlayer, action GameAction) (sqlc.CreateGameEventParams, error) {
+	t, err := actionType(action)
+	if err != nil {
+		return sqlc.CreateGameEventParams{}, err
+	}
+	payload, err := json.Marshal(action)
+	if err != nil {
+		return sqlc.CreateGameEventParams{}, err
+	}
+
+	return sqlc.CreateGameEventParams{
+		GameID:    gameID,
+		Seq:       version,
+		Type:      string(t),
+		ActorID:   actor.ID,
+		ActorName: actor.Name,
+		Payload:   payload,
+	}, nil
+}
+
+// recordEvent appends an accepted action to the game's event log. The live
+// state is already updated at this point, so writes are retried, then queued
+// for the persister, as replays need every seq. Players are only told if the
+// event could not be queued either.
+func (g *Game) recordEvent(ctx context.Context, version int64, actor dto.GameStatePlayer, action GameAction) {
+	params, err := newActionEvent(g.ID, version, actor, action)
+	if err == nil {
+		err = g.hub.writeEvent(ctx, params)
+		if err != nil && !isEventSeqTaken(err) {
+			g.hub.logger.Warn("Queueing game event", "gameId", g.ID, "version", version, "err", err)
+			err = g.hub.queueEvent(ctx, params)
+			if err == nil {
+				eventMetrics.Add("queued", 1)
+				return
+			}
+		}
+	}
+	if err != nil {
+		eventMetrics.Add("failed", 1)
+		g.hub.logger.Error("Could not record game event", "gameId", g.ID, "version", version, "err", err)
+		g.broadcast(ctx, Message{Type: MsgError, GameID: &g.ID, Data: ErrorData{Message: "Game history could not be saved, replays of this game may be incomplete", Err: err.Error()}})
+		return
+	}
+	eventMetrics.Add("ok", 1)
+}
+
+// queueEvent keeps an event in Redis until writePendingEvents stores it.
+func (h *GameHub) queueEvent(ctx context.Context, params sqlc.CreateGameEventParams) error {
+	js, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	return h.rdb.RPush(ctx, redisPendingEventsKey, js).Err()
+}
+
+// writePendingEvents stores queued events, stopping at the first write that
+// fails. Entries are only removed once written, and any node may write them,
+// as a taken seq means the event is already in the log.
+func (h *GameHub) writePendingEvents(ctx context.Context) error {
+	entries, err := h.rdb.LRange(ctx, redisPendingEventsKey, 0, pendingEventsBatch-1).Result()
+	if err != nil {
+		return err
+	}
+
+	for _, raw := range entries {
+		var params sqlc.CreateGameEventParams
+		if err := json.Unmarshal([]byte(raw), &params); err != nil {
+			h.logger.Error("Dropping malformed queued game event", "err", err)
+		} else {
+			_, err := h.db.Queries.CreateGameEvent(ctx, params)
+			if err != nil && !isEventSeqTaken(err) {
+				return fmt.Errorf("could not write queued event %d of game %s: %w", params.Seq, params.GameID, err)
+			}
+			eventMetrics.Add("ok", 1)
+		}
+
+		if err := h.rdb.LRem(ctx, redisPendingEventsKey, 1, raw).Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeEvent inserts an event, retrying failures other than a taken seq,
+// which no retry can fix.
+func (h *GameHub) writeEvent(ctx context.Context, params sqlc.CreateGameEventParams) error {
+	var err error
+	for attempt := range eventWriteAttempts {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return err
+			case <-time.After(time.Duration(attempt) * eventWriteBackoff):
+			}
+		}
+
+		_, err = h.db.Queries.CreateGameEvent(ctx, params)
+		if err == nil || isEventSeqTaken(err) {
+			return err
+		}
+	}
+	return err
+}
+
+func isEventSeqTaken(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == "uq_game_events_game_seq"
+}
+
+// eventAt finds the event with the given seq in events ordered by seq. Seqs
+// are not indexes, the log may be missing some.
+func eventAt(events []sqlc.GameEvent, seq int64) (sqlc.GameEvent, bool) {
+	i, found := slices.BinarySearchFunc(events, seq, func(e sqlc.GameEvent, seq int64) int {
+		return cmp.Compare(e.Seq, seq)
+	})
+	if !found {
+		return sqlc.GameEvent{}, false
+	}
+	return events[i], true
+}
+
+// ReplayGameEvents folds a game's events, ordered by seq, back into its
+// state. It stops after the event with seq upTo, or replays everything when
+// upTo is negative.
+func ReplayGameEvents(events []sqlc.GameEvent, upTo int64) (dto.GameState, error) {
+	return foldGameEvents(events, upTo, nil)
+}
+
+// foldGameEvents replays events like ReplayGameEvents, calling step with the
+// state after each of them, including the creation.
+func foldGameEvents(events []sqlc.GameEvent, upTo int64, step func(event sqlc.GameEvent, gs *dto.GameState) error) (dto.GameState, error) {
+	if len(events) == 0 || events[0].Type != EventGameCreated {
+		return dto.GameState{}, fmt.Errorf("%w: missing %s event", ErrInvalidEventLog, EventGameCreated)
+	}
+
+	gs, err := decodeGameState("[" + string(events[0].Payload) + "]")
+	if err != nil {
+		return dto.GameState{}, err
+	}
+	gs.Version = events[0].Seq
+
+	for i, event := range events {
+		if upTo >= 0 && event.Seq > upTo {
+			break
+		}
+
+		if i > 0 {
+			if event.Seq != gs.Version+1 {
+				return dto.GameState{}, fmt.Errorf("%w: expected seq %d, got %d", ErrInvalidEventLog, gs.Version+1, event.Seq)
+			}
+
+			action, err := decodeAction(MessageType(event.Type), event.Payload)
+			if err != nil {
+				return dto.GameState{}, fmt.Errorf("%w: event %d: %w", ErrInvalidEventLog, event.Seq, err)
+			}
+
+			actor := dto.GameStatePlayer{ID: event.ActorID, Name: event.ActorName}
+			if err := action.Apply(&gs, actor); err != nil {
+				return dto.GameState{}, fmt.Errorf("%w: event %d: %w", ErrInvalidEventLog, event.Seq, err)
+			}
+			gs.Version = event.Seq
+		}
+
+		if step != nil {
+			if err := step(event, &gs); err != nil {
+				return dto.GameState{}, err
+			}
+		}
+	}
+
+	return gs, nil
+}
+
+// ReplayEvent is one accepted action of a replay, with the state it produced.
+type ReplayEvent struct {
+	Seq       int64               `json:"seq"`
+	Type      string              `json:"type"`
+	Actor     dto.GameStatePlayer `json:"actor"`
+	Payload   json.RawMessage     `json:"payload"`
+	CreatedAt time.Time           `json:"created_at"`
+	State     json.RawMessage     `json:"state,omitempty"`
+}
+
+func newReplayEvent(event sqlc.GameEvent) ReplayEvent {
+	payload := json.RawMessage(event.Payload)
+	if event.Type == EventGameCreated {
+		// The initial state is part of every timeline entry already.
+		payload = json.RawMessage("null")
+	}
+
+	return ReplayEvent{
+		Seq:       event.Seq,
+		Type:      event.Type,
+		Actor:     dto.GameStatePlayer{ID: event.ActorID, Name: event.ActorName},
+		Payload:   payload,
+		CreatedAt: event.CreatedAt.Time,
+	}
+}
+
+// ReplayTimeline returns every event of the game with the state after it.
+// Replays show the whole key, as they are only available for ended games.
+func ReplayTimeline(events []sqlc.GameEvent) ([]ReplayEvent, error) {
+	timeline := make([]ReplayEvent, 0, len(events))
+
+	_, err := foldGameEvents(events, -1, func(event sqlc.GameEvent, gs *dto.GameState) error {
+		state, err := json.Marshal(gs)
+		if err != nil {
+			return err
+		}
+
+		entry := newReplayEvent(event)
+		entry.State = state
+		timeline = append(timeline, entry)
+		return nil
+	})
+
+	return timeline, err
+}
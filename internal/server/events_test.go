@@ -0,0 +1,126 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/ninox14/gore-codenames/internal/database/dto"
+	"github.com/ninox14/gore-codenames/internal/database/sqlc"
+)
+
+func TestReplayMatchesLiveState(t *testing.T) {
+	gameID := uuid.New()
+	users := make([]sqlc.User, 4)
+	players := make([]dto.GameStatePlayer, 4)
+	for i := range users {
+		users[i] = sqlc.User{ID: uuid.New(), Name: "player"}
+		players[i] = dto.GameStatePlayer{ID: users[i].ID, Name: users[i].Name}
+	}
+
+	live := dto.GameState{
+		HostID:     users[0].ID,
+		Settings:   DefaultGameSettings(),
+		Phase:      dto.GamePhaseLobby,
+		Spectators: []dto.GameStatePlayer{},
+		Teams:      map[dto.TeamColor]*dto.Team{dto.TeamColorRed: CreateEmptyTeam(), dto.TeamColorBlue: CreateEmptyTeam()},
+		Board: InitBoardStateFromWordPack(sqlc.Wordpack{Words: []string{
+			"a", "b", "c", "d", "e", "f", "g", "h", "i", "j", "k", "l", "m",
+			"n", "o", "p", "q", "r", "s", "t", "u", "v", "w", "x", "y", "z",
+		}}, DefaultMaxWordsPerTeam, DefaultAssassinCount, GetDefaultBoardSize()),
+	}
+
+	created, err := newCreatedEvent(gameID, users[0], &live)
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := []sqlc.GameEvent{{GameID: gameID, Seq: created.Seq, Type: created.Type, ActorID: created.ActorID, ActorName: created.ActorName, Payload: created.Payload}}
+
+	play := func(actor dto.GameStatePlayer, action GameAction) {
+		t.Helper()
+		if err := action.Apply(&live, actor); err != nil {
+			t.Fatalf("%T: %v", action, err)
+		}
+		live.Version++
+
+		e, err := newActionEvent(gameID, live.Version, actor, action)
+		if err != nil {
+			t.Fatal(err)
+		}
+		events = append(events, sqlc.GameEvent{GameID: gameID, Seq: e.Seq, Type: e.Type, ActorID: e.ActorID, ActorName: e.ActorName, Payload: e.Payload})
+	}
+
+	first := live.Board.TurnOrder[0]
+	paths := map[dto.TeamColor]RedisPlayersPath{dto.TeamColorRed: TeamRedPath, dto.TeamColorBlue: TeamBluePath}
+	second := otherTeam(&live, first)
+
+	for i, p := range players {
+		play(p, JoinAction{})
+		if i < 2 {
+			play(p, ChangeTeamData{Destination: paths[first]})
+		} else {
+			play(p, ChangeTeamData{Destination: paths[second]})
+		}
+	}
+	play(players[0], SetCaptainData{})
+	play(players[0], SetCaptainData{PlayerID: &players[2].ID})
+	play(players[0], StartGameData{})
+	play(players[0], GiveClueData{Word: "clue", Number: 2})
+	play(players[1], GuessCardData{Index: live.Board.WordsByTeam[first][0]})
+	play(players[1], EndTurnData{})
+
+	replayed, err := ReplayGameEvents(events, -1)
+	if err != nil {
+		t.Fatalf("replay: %v", err)
+	}
+
+	want, _ := json.Marshal(live)
+	got, _ := json.Marshal(replayed)
+	if string(want) != string(got) {
+		t.Errorf("replayed state differs from live state\nwant %s\ngot  %s", want, got)
+	}
+
+	partial, err := ReplayGameEvents(events, 2)
+	if err != nil {
+		t.Fatalf("partial replay: %v", err)
+	}
+	if partial.Version != 2 || len(partial.Spectators)+len(partial.Teams[first].Players) != 1 {
+		t.Errorf("expected one joined player at version 2; got version %d", partial.Version)
+	}
+}
+
+func TestReplayWithGapInLog(t *testing.T) {
+	gs, players := newTestGame(t)
+	gs.Phase = dto.GamePhaseLobby
+	gs.Turn = nil
+
+	created, err := newCreatedEvent(uuid.New(), sqlc.User{ID: players[0].ID, Name: players[0].Name}, gs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := []sqlc.GameEvent{{Seq: created.Seq, Type: created.Type, ActorID: created.ActorID, Payload: created.Payload}}
+	for _, seq := range []int64{1, 5} {
+		e, err := newActionEvent(created.GameID, seq, players[1], ChangeTeamData{Destination: SpectatorsPath})
+		if err != nil {
+			t.Fatal(err)
+		}
+		events = append(events, sqlc.GameEvent{Seq: e.Seq, Type: e.Type, ActorID: e.ActorID, Payload: e.Payload})
+	}
+
+	for _, step := range []int64{2, 4} {
+		if _, ok := eventAt(events, step); ok {
+			t.Errorf("expected step %d to be missing", step)
+		}
+	}
+	if e, ok := eventAt(events, 5); !ok || e.Seq != 5 {
+		t.Errorf("expected to find step 5; got %d", e.Seq)
+	}
+
+	if _, err := ReplayGameEvents(events, 1); err != nil {
+		t.Errorf("expected steps before the gap to replay; got %v", err)
+	}
+	if _, err := ReplayGameEvents(events, 5); !errors.Is(err, ErrInvalidEventLog) {
+		t.Errorf("expected the gap to be reported; got %v", err)
+	}
+}
@@ -0,0 +1,117 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+func GetRedisBroadcastChannel(gameID uuid.UUID) string {
+	return fmt.Sprintf("game:%s:broadcast", gameID)
+}
+
+// broadcastEnvelope is what travels over the per-game pub/sub channel.
+// Recipients are resolved from the shared game state by the publisher, so
+// every node can filter its local sockets without knowing the others.
+type broadcastEnvelope struct {
+	Message    json.RawMessage `json:"message"`
+	Recipients []uuid.UUID     `json:"recipients,omitempty"`
+	Everyone   bool            `json:"everyone"`
+	// Close asks nodes to close the recipients' sockets after delivery.
+	Close bool `json:"close,omitempty"`
+}
+
+// publish hands msg to every node that has sockets in the game, including
+// this one. A nil recipients slice means everyone.
+func (g *Game) publish(ctx context.Context, msg Message, recipients []uuid.UUID) {
+	g.publishEnvelope(ctx, msg, recipients, false)
+}
+
+func (g *Game) publishEnvelope(ctx context.Context, msg Message, recipients []uuid.UUID, close bool) {
+	js, err := json.Marshal(msg)
+	if err != nil {
+		g.hub.logger.Error("Error encoding broadcast", "gameId", g.ID, "error", err)
+		return
+	}
+
+	envelope, err := json.Marshal(broadcastEnvelope{Message: js, Recipients: recipients, Everyone: recipients == nil, Close: close})
+	if err != nil {
+		g.hub.logger.Error("Error encoding broadcast", "gameId", g.ID, "error", err)
+		return
+	}
+
+	if err := g.hub.rdb.Publish(ctx, GetRedisBroadcastChannel(g.ID), envelope).Err(); err != nil {
+		g.hub.logger.Error("Error publishing broadcast", "gameId", g.ID, "error", err)
+	}
+}
+
+// deliver writes a published message to the local sockets it is meant for,
+// encoding it once per codec in use.
+func (g *Game) deliver(ctx context.Context, envelope broadcastEnvelope) {
+	g.mu.RLock()
+	players := make([]*Player, 0, len(g.Players))
+	for _, p := range g.Players {
+		if p.Client != nil && (envelope.Everyone || slices.Contains(envelope.Recipients, p.ID)) {
+			players = append(players, p)
+		}
+	}
+	g.mu.RUnlock()
+
+	frames := map[Codec][]byte{JSONCodec: envelope.Message}
+
+	for _, player := range players {
+		codec := player.Protocol.Codec
+		frame, ok := frames[codec]
+		if !ok {
+			var err error
+			if frame, err = codec.Marshal(envelope.Message); err != nil {
+				g.hub.logger.Error("Error encoding broadcast", "codec", codec.Name(), "error", err)
+				continue
+			}
+			frames[codec] = frame
+		}
+
+		if err := player.Conn.Write(ctx, codec.FrameType(), frame); err != nil {
+			g.hub.logger.Error("Error broadcasting to player", "player", player.ID, "error", err)
+			// Remove player if connection is broken
+			go g.RemovePlayer(context.Background(), player.ID)
+			continue
+		}
+
+		if envelope.Close {
+			g.closePlayer(player.ID, "Removed by the host")
+		}
+	}
+}
+
+// subscribe starts relaying the game's channel to its local sockets. It waits
+// for Redis to confirm the subscription, so broadcasts that follow a join
+// are not missed.
+func (g *Game) subscribe(ctx context.Context) error {
+	g.sub = g.hub.rdb.Subscribe(ctx, GetRedisBroadcastChannel(g.ID))
+	if _, err := g.sub.Receive(ctx); err != nil {
+		return fmt.Errorf("could not subscribe to game %s: %w", g.ID, err)
+	}
+
+	go g.relay(g.sub.Channel())
+	return nil
+}
+
+// relay runs until the subscription is closed by RemoveGame.
+func (g *Game) relay(ch <-chan *redis.Message) {
+	ctx := context.Background()
+
+	for m := range ch {
+		var envelope broadcastEnvelope
+		if err := json.Unmarshal([]byte(m.Payload), &envelope); err != nil {
+			g.hub.logger.Error("Invalid broadcast envelope", "gameId", g.ID, "error", err)
+			continue
+		}
+
+		g.deliver(ctx, envelope)
+	}
+}
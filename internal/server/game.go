@@ -22,6 +22,14 @@ func GetDefaultBoardSize() *dto.BoardSize {
 	}
 }
 
+func DefaultGameSettings() dto.GameSettings {
+	return dto.GameSettings{
+		MuteSpymastersInTeamChat: true,
+		MaxPlayersPerTeam:        DefaultMaxPlayersPerTeam,
+		MaxSpectators:            DefaultMaxSpectators,
+	}
+}
+
 func CreateEmptyTeam() *dto.Team {
 	return &dto.Team{CaptainID: nil, Players: make([]dto.GameStatePlayer, 0), Clues: make([]*dto.Clue, 0)}
 }
@@ -41,6 +49,8 @@ func GetInitialGameState(ctx context.Context, user *sqlc.User, db *database.DB,
 	return &dto.GameState{
 		HostID:     user.ID,
 		WordPackID: wordpack.ID,
+		Settings:   DefaultGameSettings(),
+		Phase:      dto.GamePhaseLobby,
 		Spectators: []dto.GameStatePlayer{},
 		Teams:      teams,
 		Board:      board,
@@ -58,11 +68,12 @@ func InitBoardStateFromWordPack(wp sqlc.Wordpack, maxWordsPerteam int, maxAssasi
 		turnOrder = []dto.TeamColor{dto.TeamColorRed, dto.TeamColorBlue}
 	}
 
-	indxs := Range(boardSize)
+	// One index per card, dealt to the assassins and then the teams.
+	indxs := Range(boardSize - 1)
 	rand.Shuffle(len(indxs), func(i, j int) {
 		indxs[i], indxs[j] = indxs[j], indxs[i]
 	})
-	assassinsIdxs, indxs := Cut(indxs, 0, 1)
+	assassinsIdxs, indxs := Cut(indxs, 0, maxAssasins)
 	firstTeamIdxs, indxs := Cut(indxs, 0, maxWordsPerteam)
 	secondTeamIdxs, _ := Cut(indxs, 0, maxWordsPerteam-1)
 
@@ -104,6 +115,7 @@ func RandRange(min, max int) int {
 	return rand.IntN(max-min) + min
 }
 
+// Range returns 0 through n, both included.
 func Range(n int) []int {
 	nums := make([]int, n+1)
 	for i := 0; i <= n; i++ {
@@ -0,0 +1,43 @@
+package server
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/ninox14/gore-codenames/internal/database/sqlc"
+)
+
+func TestInitBoardDealsEveryCardOnce(t *testing.T) {
+	words := make([]string, 30)
+	for i := range words {
+		words[i] = fmt.Sprintf("word%d", i)
+	}
+	size := GetDefaultBoardSize()
+	cards := size.X * size.Y
+
+	for _, assassins := range []int{1, 2} {
+		board := InitBoardStateFromWordPack(sqlc.Wordpack{Words: words}, DefaultMaxWordsPerTeam, assassins, size)
+
+		if len(board.CurrentBoard) != cards {
+			t.Fatalf("expected %d cards; got %d", cards, len(board.CurrentBoard))
+		}
+		if len(board.AssassinIndexs) != assassins {
+			t.Errorf("expected %d assassins; got %d", assassins, len(board.AssassinIndexs))
+		}
+		if first, second := board.TurnOrder[0], board.TurnOrder[1]; len(board.WordsByTeam[first]) != DefaultMaxWordsPerTeam || len(board.WordsByTeam[second]) != DefaultMaxWordsPerTeam-1 {
+			t.Errorf("expected the first team to get one card more; got %d and %d", len(board.WordsByTeam[first]), len(board.WordsByTeam[second]))
+		}
+
+		dealt := append([]int{}, board.AssassinIndexs...)
+		for _, idxs := range board.WordsByTeam {
+			dealt = append(dealt, idxs...)
+		}
+		seen := make(map[int]bool)
+		for _, i := range dealt {
+			if i < 0 || i >= cards || seen[i] {
+				t.Errorf("expected every card to be dealt at most once and on the board; got %d twice or out of range", i)
+			}
+			seen[i] = true
+		}
+	}
+}
@@ -8,14 +8,18 @@ import (
 	"log"
 	"log/slog"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"time"
 
 	"github.com/coder/websocket"
-	"github.com/coder/websocket/wsjson"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/ninox14/gore-codenames/internal/database/dto"
 	"github.com/ninox14/gore-codenames/internal/database/lib"
 	"github.com/ninox14/gore-codenames/internal/database/sqlc"
+	"github.com/ninox14/gore-codenames/internal/printing"
 	"github.com/ninox14/gore-codenames/internal/request"
 	"github.com/ninox14/gore-codenames/internal/response"
 	"github.com/ninox14/gore-codenames/internal/validator"
@@ -144,19 +148,102 @@ func (s *Server) createNewGame(w http.ResponseWriter, r *http.Request) {
 		s.serverError(w, r, errors.New("failed to retrieve user data from request context"))
 		return
 	}
+	var input struct {
+		Settings     *dto.GameSettings   `json:"settings"`
+		Visibility   sqlc.GameVisibility `json:"visibility"`
+		AccessPolicy sqlc.GameAccess     `json:"access_policy"`
+		Password     string              `json:"password"`
+	}
+
+	// Settings left out of the request keep their defaults.
+	settings := DefaultGameSettings()
+	input.Settings = &settings
+
+	if r.ContentLength != 0 {
+		err := request.DecodeJSON(w, r, &input)
+		if err != nil {
+			s.badRequest(w, r, err)
+			return
+		}
+	}
+	if input.Settings == nil {
+		input.Settings = &settings
+	}
+
+	if input.Visibility == "" {
+		input.Visibility = sqlc.GameVisibilityPublic
+	}
+	if input.AccessPolicy == "" {
+		input.AccessPolicy = sqlc.GameAccessOpen
+	}
+
+	var v validator.Validator
+	v.CheckField(validator.In(input.Visibility, sqlc.GameVisibilityPublic, sqlc.GameVisibilityUnlisted, sqlc.GameVisibilityPrivate), "visibility", "Must be public, unlisted or private")
+	v.CheckField(validator.In(input.AccessPolicy, sqlc.GameAccessOpen, sqlc.GameAccessPassword, sqlc.GameAccessInvite), "access_policy", "Must be open, password or invite")
+	if input.AccessPolicy == sqlc.GameAccessPassword {
+		v.CheckField(validator.MinRunes(input.Password, MinGamePasswordLen), "password", fmt.Sprintf("Must be at least %d characters long", MinGamePasswordLen))
+		v.CheckField(len(input.Password) <= MaxGamePasswordLen, "password", fmt.Sprintf("Must not be more than %d bytes long", MaxGamePasswordLen))
+	} else {
+		v.CheckField(input.Password == "", "password", "Only password protected games take a password")
+	}
+	// Zero leaves the seats uncapped.
+	v.CheckField(input.Settings.MaxPlayersPerTeam == 0 || validator.Between(input.Settings.MaxPlayersPerTeam, 2, MaxTeamSize), "settings.max_players_per_team", fmt.Sprintf("Must be 0 for no limit, or between 2 and %d", MaxTeamSize))
+	v.CheckField(input.Settings.MaxSpectators == 0 || validator.Between(input.Settings.MaxSpectators, 1, MaxSpectatorSeats), "settings.max_spectators", fmt.Sprintf("Must be 0 for no limit, or between 1 and %d", MaxSpectatorSeats))
+
+	if v.HasErrors() {
+		s.failedValidation(w, r, v)
+		return
+	}
+
+	var passwordHash pgtype.Text
+	if input.AccessPolicy == sqlc.GameAccessPassword {
+		hash, err := hashGamePassword(input.Password)
+		if err != nil {
+			s.serverError(w, r, err)
+			return
+		}
+		passwordHash = hash
+	}
+
 	initGameState, err := GetInitialGameState(r.Context(), &user, s.db, s.logger)
-	if err != err {
+	if err != nil {
 		s.serverError(w, r, err)
 		return
 	}
+	initGameState.Settings = *input.Settings
 	gameId := uuid.New()
 
-	_, err = s.db.Queries.CreateGame(r.Context(), sqlc.CreateGameParams{
-		ID:         gameId,
-		HostID:     user.ID,
-		WordPackID: initGameState.WordPackID,
-		GameState:  initGameState,
-	})
+	createdEvent, err := newCreatedEvent(gameId, user, initGameState)
+	if err != nil {
+		s.serverError(w, r, err)
+		return
+	}
+
+	var joinCode string
+	for range joinCodeAttempts {
+		joinCode = NewJoinCode()
+		err = s.db.WithTx(r.Context(), func(q *sqlc.Queries) error {
+			_, err := q.CreateGame(r.Context(), sqlc.CreateGameParams{
+				ID:           gameId,
+				HostID:       user.ID,
+				WordPackID:   initGameState.WordPackID,
+				GameState:    initGameState,
+				JoinCode:     joinCodeText(joinCode),
+				Visibility:   input.Visibility,
+				AccessPolicy: input.AccessPolicy,
+				PasswordHash: passwordHash,
+			})
+			if err != nil {
+				return err
+			}
+
+			_, err = q.CreateGameEvent(r.Context(), createdEvent)
+			return err
+		})
+		if !isJoinCodeTaken(err) {
+			break
+		}
+	}
 
 	if err != nil {
 		s.serverError(w, r, err)
@@ -164,19 +251,23 @@ func (s *Server) createNewGame(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	redisKey := fmt.Sprintf("game:%s", gameId)
+	redisKey := GetRedisGameKey(gameId)
 
-	// TODO: change redis game expiry
-	err = s.rdb.JSONSet(r.Context(), redisKey, "$", initGameState).Err()
+	pipe := s.rdb.TxPipeline()
+	pipe.JSONSet(r.Context(), redisKey, "$", initGameState)
+	s.gh.touchGame(r.Context(), pipe, gameId)
+	_, err = pipe.Exec(r.Context())
 	if err != nil {
 		s.serverError(w, r, err)
 		return
 	}
 
 	resp := struct {
-		GameID uuid.UUID `json:"game_id"`
+		GameID   uuid.UUID `json:"game_id"`
+		JoinCode string    `json:"join_code"`
 	}{
-		GameID: gameId,
+		GameID:   gameId,
+		JoinCode: joinCode,
 	}
 
 	response.JSON(w, http.StatusOK, resp)
@@ -190,14 +281,53 @@ func (s *Server) websocketHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	gameIdStr := r.URL.Query().Get("gameId") // or however you pass it
-	gameId, err := uuid.Parse(gameIdStr)
+	query := r.URL.Query()
+
+	var (
+		game sqlc.Game
+		err  error
+	)
+	if code := query.Get("code"); code != "" && query.Get("gameId") == "" {
+		game, err = s.gh.ResolveJoinCode(r.Context(), code)
+	} else {
+		var gameId uuid.UUID
+		gameId, err = uuid.Parse(query.Get("gameId"))
+		if err != nil {
+			s.badRequest(w, r, err)
+			return
+		}
+		game, err = s.db.Queries.GetGameByID(r.Context(), gameId)
+	}
+	if err != nil {
+		s.notFound(w, r)
+		return
+	}
+	gameId := game.ID
+
+	// Browsers cannot set headers on a websocket handshake, so credentials
+	// come in the query like the authentication token does.
+	err = s.checkGameAccess(r.Context(), game, user.ID, GameCredentials{
+		Password: query.Get("password"),
+		Invite:   query.Get("invite"),
+	})
+	if err != nil {
+		s.accessDenied(w, r, err)
+		return
+	}
+
+	protocol, subprotocol, err := negotiateProtocol(r)
 	if err != nil {
 		s.badRequest(w, r, err)
 		return
 	}
 
-	c, err := websocket.Accept(w, r, &websocket.AcceptOptions{
+	var subprotocols []string
+	if subprotocol != "" {
+		subprotocols = []string{subprotocol}
+	}
+
+	conn, err := websocket.Accept(w, r, &websocket.AcceptOptions{
+		Subprotocols: subprotocols,
 		// FIXME: add origin check on deploy
 		InsecureSkipVerify: true,
 	})
@@ -206,9 +336,12 @@ func (s *Server) websocketHandler(w http.ResponseWriter, r *http.Request) {
 		s.serverError(w, r, err)
 		return
 	}
+	c := &Client{Conn: conn, Protocol: protocol, GameID: gameId}
 	bgCtx := context.Background()
 	ctx, cancel := context.WithCancel(bgCtx)
+	limiter := s.gh.limiters.Acquire(user.ID)
 	defer func() {
+		s.gh.limiters.Release(user.ID)
 		// Remove player from game when connection closes
 		game := s.gh.GetGame(gameId)
 		if game != nil {
@@ -216,14 +349,22 @@ func (s *Server) websocketHandler(w http.ResponseWriter, r *http.Request) {
 		}
 		s.logger.Debug("Closed with canceling context")
 		cancel()
-		c.Close(websocket.StatusGoingAway, "Normal closure")
+		conn.Close(websocket.StatusGoingAway, "Normal closure")
 	}()
 
-	go websocketPingLoop(ctx, c, user.ID, gameId, s.gh)
+	go websocketPingLoop(ctx, conn, user.ID, gameId, s.gh)
+
+	err = c.Send(ctx, Message{
+		Type: MsgHello,
+		Data: HelloData{ProtocolVersion: protocol.Version, SupportedVersions: SupportedProtocolVersions},
+	})
+	if err != nil {
+		s.logger.Error("Failed to send hello", "error", err)
+		return
+	}
 
 	for {
-		var msg Message
-		err := wsjson.Read(ctx, c, &msg)
+		_, data, err := conn.Read(ctx)
 
 		switch websocket.CloseStatus(err) {
 		case websocket.StatusNormalClosure, websocket.StatusGoingAway:
@@ -231,11 +372,569 @@ func (s *Server) websocketHandler(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		if err != nil {
-			s.logger.Error("JSON unmarshal error", "error", err)
+			s.logger.Error("Websocket read error", "error", err)
 			break
 		}
 
+		var msg Message
+		if err := protocol.Codec.Unmarshal(data, &msg); err != nil {
+			if limiter.Violation() {
+				s.logger.Warn("Closing abusive websocket", "userId", user.ID)
+				conn.Close(websocket.StatusPolicyViolation, "Too many invalid messages")
+				return
+			}
+			writeErrorMessage(ctx, c, "Invalid message", err)
+			continue
+		}
+		spec, _ := LookupMessage(ClientMessage, msg.Type)
+		if spec.Since > protocol.Version {
+			writeErrorMessage(ctx, c, "Invalid message", fmt.Errorf("%s requires protocol version %d", msg.Type, spec.Since))
+			continue
+		}
+		if !limiter.Allow(spec.Class) {
+			if limiter.Violation() {
+				s.logger.Warn("Closing abusive websocket", "userId", user.ID)
+				conn.Close(websocket.StatusPolicyViolation, "Rate limit exceeded")
+				return
+			}
+			writeErrorMessage(ctx, c, "Rate limit exceeded", fmt.Errorf("too many %s messages, slow down", spec.Class))
+			continue
+		}
+
 		s.logger.Debug("Incoming message", "message", msg)
 		processWSMessage(ctx, &msg, c, user, s.gh)
 	}
 }
+
+func (s *Server) createSpectatorToken(w http.ResponseWriter, r *http.Request) {
+	user, ok := contextGetAuthenticatedUser(r)
+	if !ok {
+		s.serverError(w, r, errors.New("failed to retrieve user data from request context"))
+		return
+	}
+
+	gameId, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		s.badRequest(w, r, err)
+		return
+	}
+
+	gs, err := s.gh.GetGameState(r.Context(), gameId)
+	if err != nil {
+		s.notFound(w, r)
+		return
+	}
+
+	_, seated := gs.TeamOf(user.ID)
+	if gs.HostID != user.ID && !seated && !gs.IsSpectator(user.ID) {
+		s.notPermitted(w, r)
+		return
+	}
+
+	token, expiry, err := s.signSpectatorToken(gameId)
+	if err != nil {
+		s.serverError(w, r, err)
+		return
+	}
+
+	data := map[string]string{
+		"SpectatorToken":       string(token),
+		"SpectatorTokenExpiry": expiry.Format(time.RFC3339),
+	}
+
+	err = response.JSON(w, http.StatusOK, data)
+	if err != nil {
+		s.serverError(w, r, err)
+	}
+}
+
+// createInviteToken lets the host of an invite-only game hand out links. Any
+// holder of the token can join until it expires.
+func (s *Server) createInviteToken(w http.ResponseWriter, r *http.Request) {
+	user, ok := contextGetAuthenticatedUser(r)
+	if !ok {
+		s.serverError(w, r, errors.New("failed to retrieve user data from request context"))
+		return
+	}
+
+	gameId, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		s.badRequest(w, r, err)
+		return
+	}
+
+	var input struct {
+		ExpiresIn int `json:"expires_in"`
+	}
+	if r.ContentLength != 0 {
+		err := request.DecodeJSON(w, r, &input)
+		if err != nil {
+			s.badRequest(w, r, err)
+			return
+		}
+	}
+
+	ttl := DefaultInviteExpiry
+	if input.ExpiresIn != 0 {
+		ttl = time.Duration(input.ExpiresIn) * time.Second
+	}
+
+	var v validator.Validator
+	v.CheckField(validator.Between(ttl, time.Minute, MaxInviteExpiry), "expires_in", fmt.Sprintf("Must be between 60 and %d seconds", int(MaxInviteExpiry.Seconds())))
+
+	if v.HasErrors() {
+		s.failedValidation(w, r, v)
+		return
+	}
+
+	game, err := s.db.Queries.GetGameByID(r.Context(), gameId)
+	if err != nil {
+		s.notFound(w, r)
+		return
+	}
+	if game.HostID != user.ID {
+		s.notPermitted(w, r)
+		return
+	}
+	if game.AccessPolicy != sqlc.GameAccessInvite {
+		s.errorMessage(w, r, http.StatusConflict, "invites are only needed for invite-only games", nil)
+		return
+	}
+
+	token, expiry, err := s.signInviteToken(gameId, ttl)
+	if err != nil {
+		s.serverError(w, r, err)
+		return
+	}
+
+	resp := struct {
+		InviteToken string    `json:"invite_token"`
+		ExpiresAt   time.Time `json:"expires_at"`
+	}{
+		InviteToken: string(token),
+		ExpiresAt:   expiry.Round(time.Second),
+	}
+
+	err = response.JSON(w, http.StatusOK, resp)
+	if err != nil {
+		s.serverError(w, r, err)
+	}
+}
+
+// spectatorEventsHandler streams the redacted game state and public events as
+// Server-Sent Events. Clients that reconnect with Last-Event-ID receive the
+// events they missed instead of a fresh snapshot. Listeners are anonymous and
+// take no seat, so MaxSpectators does not apply to them.
+func (s *Server) spectatorEventsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	gameId, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		s.badRequest(w, r, err)
+		return
+	}
+
+	game, err := s.db.Queries.GetGameByID(ctx, gameId)
+	if err != nil {
+		s.notFound(w, r)
+		return
+	}
+	if err := s.checkViewAccess(r, game); err != nil {
+		s.viewAccessDenied(w, r, err)
+		return
+	}
+
+	gs, err := s.gh.GetGameState(ctx, gameId)
+	if err != nil {
+		s.notFound(w, r)
+		return
+	}
+
+	lastID := r.Header.Get("Last-Event-ID")
+	snapshot := lastID == ""
+	if snapshot {
+		lastID, err = s.gh.lastSpectatorEventID(ctx, gameId)
+		if err != nil {
+			s.serverError(w, r, err)
+			return
+		}
+	}
+
+	// The stream outlives the server's write timeout.
+	rc := http.NewResponseController(w)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		s.serverError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	if snapshot {
+		js, err := json.Marshal(gs.Redacted())
+		if err != nil {
+			s.reportServerError(r, err)
+			return
+		}
+		writeSSE(w, lastID, string(MsgGameState), js)
+	}
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	for {
+		events, err := s.gh.readSpectatorEvents(ctx, gameId, lastID)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			s.reportServerError(r, err)
+			return
+		}
+
+		if len(events) == 0 {
+			fmt.Fprint(w, ": keep-alive\n\n")
+		}
+		for _, e := range events {
+			t, _ := e.Values["type"].(string)
+			data, _ := e.Values["data"].(string)
+			if err := writeSSE(w, e.ID, t, []byte(data)); err != nil {
+				return
+			}
+			lastID = e.ID
+		}
+
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// gameReplayHandler returns the timeline of an ended game with the fully
+// revealed state after every event, or only the state at ?step=N.
+func (s *Server) gameReplayHandler(w http.ResponseWriter, r *http.Request) {
+	gameId, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		s.badRequest(w, r, err)
+		return
+	}
+
+	step := int64(-1)
+	if raw := r.URL.Query().Get("step"); raw != "" {
+		step, err = strconv.ParseInt(raw, 10, 64)
+		if err != nil || step < 0 {
+			s.badRequest(w, r, errors.New("step must be a non-negative integer"))
+			return
+		}
+	}
+
+	game, err := s.db.Queries.GetGameByID(r.Context(), gameId)
+	if err != nil {
+		s.notFound(w, r)
+		return
+	}
+	if err := s.checkViewAccess(r, game); err != nil {
+		s.viewAccessDenied(w, r, err)
+		return
+	}
+	if game.Status != sqlc.GameStatusFinished && game.Status != sqlc.GameStatusAbandoned {
+		s.errorMessage(w, r, http.StatusConflict, "replays are only available once the game has ended", nil)
+		return
+	}
+
+	events, err := s.db.Queries.ListGameEvents(r.Context(), gameId)
+	if err != nil {
+		s.serverError(w, r, err)
+		return
+	}
+	if len(events) == 0 {
+		s.notFound(w, r)
+		return
+	}
+	lastStep := events[len(events)-1].Seq
+
+	if step < 0 {
+		timeline, err := ReplayTimeline(events)
+		if errors.Is(err, ErrInvalidEventLog) {
+			s.errorMessage(w, r, http.StatusConflict, "the history of this game is incomplete", nil)
+			return
+		}
+		if err != nil {
+			s.serverError(w, r, err)
+			return
+		}
+
+		resp := struct {
+			GameID uuid.UUID       `json:"game_id"`
+			Status sqlc.GameStatus `json:"status"`
+			Steps  int64           `json:"steps"`
+			Events []ReplayEvent   `json:"events"`
+		}{
+			GameID: gameId,
+			Status: game.Status,
+			Steps:  lastStep,
+			Events: timeline,
+		}
+		response.JSON(w, http.StatusOK, resp)
+		return
+	}
+
+	event, ok := eventAt(events, step)
+	if !ok {
+		s.errorMessage(w, r, http.StatusNotFound, fmt.Sprintf("step %d is not in the history of this game", step), nil)
+		return
+	}
+
+	gs, err := ReplayGameEvents(events, step)
+	if errors.Is(err, ErrInvalidEventLog) {
+		s.errorMessage(w, r, http.StatusConflict, "the history of this game is incomplete up to this step", nil)
+		return
+	}
+	if err != nil {
+		s.serverError(w, r, err)
+		return
+	}
+
+	resp := struct {
+		GameID uuid.UUID     `json:"game_id"`
+		Step   int64         `json:"step"`
+		Steps  int64         `json:"steps"`
+		Event  ReplayEvent   `json:"event"`
+		State  dto.GameState `json:"state"`
+	}{
+		GameID: gameId,
+		Step:   step,
+		Steps:  lastStep,
+		Event:  newReplayEvent(event),
+		State:  gs,
+	}
+	response.JSON(w, http.StatusOK, resp)
+}
+
+// gameTranscriptHandler renders an ended game from its games row, so it
+// works after the Redis state is gone.
+func (s *Server) gameTranscriptHandler(w http.ResponseWriter, r *http.Request) {
+	gameId, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		s.badRequest(w, r, err)
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "json"
+	}
+	if !validator.In(format, "md", "json") {
+		s.badRequest(w, r, errors.New("format must be md or json"))
+		return
+	}
+
+	game, err := s.db.Queries.GetGameByID(r.Context(), gameId)
+	if err != nil {
+		s.notFound(w, r)
+		return
+	}
+	if err := s.checkViewAccess(r, game); err != nil {
+		s.viewAccessDenied(w, r, err)
+		return
+	}
+	if game.Status != sqlc.GameStatusFinished && game.Status != sqlc.GameStatusAbandoned {
+		s.errorMessage(w, r, http.StatusConflict, "transcripts are only available once the game has ended", nil)
+		return
+	}
+
+	transcript, err := NewTranscript(game)
+	if err != nil {
+		s.serverError(w, r, err)
+		return
+	}
+
+	if format == "json" {
+		response.JSON(w, http.StatusOK, transcript)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=\"codenames-%s.md\"", gameId))
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(transcript.Markdown()))
+}
+
+// printGameHandler renders the game's board or the spymaster key as SVG, or
+// both as a PDF bundle, for playing on paper. Only the host can print, as the
+// key reveals every card.
+func (s *Server) printGameHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := contextGetAuthenticatedUser(r)
+	if !ok {
+		s.serverError(w, r, errors.New("failed to retrieve user data from request context"))
+		return
+	}
+
+	gameId, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		s.badRequest(w, r, err)
+		return
+	}
+
+	query := r.URL.Query()
+	sheet := printing.SheetKind(query.Get("sheet"))
+	if sheet == "" {
+		sheet = printing.SheetBoard
+	}
+	format := query.Get("format")
+	if format == "" {
+		format = "svg"
+	}
+
+	var v validator.Validator
+	v.CheckField(validator.In(sheet, printing.SheetBoard, printing.SheetKey), "sheet", "Must be board or key")
+	v.CheckField(validator.In(format, "svg", "pdf"), "format", "Must be svg or pdf")
+	if v.HasErrors() {
+		s.failedValidation(w, r, v)
+		return
+	}
+
+	gs, err := s.gh.GetGameState(r.Context(), gameId)
+	if errors.Is(err, ErrGameStateMissing) {
+		// Ended games only live in Postgres.
+		var game sqlc.Game
+		if game, err = s.db.Queries.GetGameByID(r.Context(), gameId); err == nil && game.GameState != nil {
+			gs = *game.GameState
+		} else {
+			err = ErrGameStateMissing
+		}
+	}
+	if err != nil || gs.Board == nil {
+		s.notFound(w, r)
+		return
+	}
+	if gs.HostID != user.ID {
+		s.notPermitted(w, r)
+		return
+	}
+
+	filename := fmt.Sprintf("codenames-%s", gameId)
+	if format == "pdf" {
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=\"%s.pdf\"", filename))
+		err = printing.WritePDF(w, printing.BoardSheet(gs.Board), printing.KeySheet(gs.Board))
+	} else {
+		page, _ := sheet.Sheet(gs.Board)
+		w.Header().Set("Content-Type", "image/svg+xml")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=\"%s-%s.svg\"", filename, sheet))
+		err = page.WriteSVG(w)
+	}
+	if err != nil {
+		s.reportServerError(r, err)
+	}
+}
+
+// resolveJoinCodeHandler serves GET /game/code/{code}. net/http cannot
+// register that pattern next to /game/{id}/events, so it is routed as the
+// catch-all for two segment game paths and only answers under "code".
+func (s *Server) resolveJoinCodeHandler(w http.ResponseWriter, r *http.Request) {
+	if r.PathValue("prefix") != "code" {
+		s.notFound(w, r)
+		return
+	}
+
+	code, err := NormalizeJoinCode(r.PathValue("code"))
+	if err != nil {
+		s.badRequest(w, r, err)
+		return
+	}
+
+	game, err := s.gh.ResolveJoinCode(r.Context(), code)
+	if err != nil {
+		s.notFound(w, r)
+		return
+	}
+
+	// The id of a protected game is only shown to those already admitted.
+	// Others join with the code and their password or invite instead.
+	var gameID *uuid.UUID
+	if s.checkViewAccess(r, game) == nil {
+		gameID = &game.ID
+	}
+
+	resp := struct {
+		GameID       *uuid.UUID      `json:"game_id"`
+		JoinCode     string          `json:"join_code"`
+		Status       sqlc.GameStatus `json:"status"`
+		AccessPolicy sqlc.GameAccess `json:"access_policy"`
+	}{
+		GameID:       gameID,
+		JoinCode:     code,
+		Status:       game.Status,
+		AccessPolicy: game.AccessPolicy,
+	}
+
+	response.JSON(w, http.StatusOK, resp)
+}
+
+// listGamesHandler lists joinable public games for the lobby browser.
+func (s *Server) listGamesHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	params := sqlc.ListLobbyGamesParams{
+		Statuses:   []string{string(sqlc.GameStatusInitial), string(sqlc.GameStatusStarted)},
+		MaxResults: DefaultLobbyPageSize,
+	}
+
+	var v validator.Validator
+
+	if raw := query.Get("status"); raw != "" {
+		params.Statuses = strings.Split(raw, ",")
+		v.CheckField(validator.AllIn(params.Statuses, string(sqlc.GameStatusInitial), string(sqlc.GameStatusStarted)), "status", "Must be Initial and/or Started")
+	}
+	if raw := query.Get("wordpack_id"); raw != "" {
+		id, err := strconv.ParseInt(raw, 10, 32)
+		v.CheckField(err == nil, "wordpack_id", "Must be an integer")
+		params.WordpackID = pgtype.Int4{Int32: int32(id), Valid: err == nil}
+	}
+	if raw := strings.TrimSpace(query.Get("host")); raw != "" {
+		escaped := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(raw)
+		params.HostName = pgtype.Text{String: "%" + escaped + "%", Valid: true}
+	}
+	if raw := query.Get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		v.CheckField(err == nil && validator.Between(limit, 1, MaxLobbyPageSize), "limit", fmt.Sprintf("Must be between 1 and %d", MaxLobbyPageSize))
+		params.MaxResults = int32(limit)
+	}
+	v.CheckField(lobbyCursorParams(query.Get("cursor"), &params) == nil, "cursor", "Is invalid")
+
+	if v.HasErrors() {
+		s.failedValidation(w, r, v)
+		return
+	}
+
+	// Fetch one more to know whether there is a next page.
+	pageSize := int(params.MaxResults)
+	params.MaxResults++
+
+	games, err := s.gh.ListLobbyGames(r.Context(), params)
+	if err != nil {
+		s.serverError(w, r, err)
+		return
+	}
+
+	var nextCursor *string
+	if len(games) > pageSize {
+		games = games[:pageSize]
+		last := games[len(games)-1]
+		cursor := encodeLobbyCursor(last.CreatedAt, last.ID)
+		nextCursor = &cursor
+	}
+
+	resp := struct {
+		Games      []LobbyGame `json:"games"`
+		NextCursor *string     `json:"next_cursor"`
+	}{
+		Games:      games,
+		NextCursor: nextCursor,
+	}
+
+	response.JSON(w, http.StatusOK, resp)
+}
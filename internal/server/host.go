@@ -0,0 +1,151 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ninox14/gore-codenames/internal/database/dto"
+	"github.com/redis/go-redis/v9"
+)
+
+// HostChangedData announces a new host, whether handed over or migrated.
+type HostChangedData struct {
+	HostID         uuid.UUID `json:"host_id"`
+	PreviousHostID uuid.UUID `json:"previous_host_id"`
+}
+
+// GetRedisConnectedKey is a sorted set of the players connected to a game on
+// any node, scored by when they connected.
+func GetRedisConnectedKey(gameID uuid.UUID) string {
+	return fmt.Sprintf("game:%s:connected", gameID)
+}
+
+// GetRedisAwayKey is a sorted set of players of a game that are not
+// connected on any node, scored by when they were last seen leaving.
+func GetRedisAwayKey(gameID uuid.UUID) string {
+	return fmt.Sprintf("game:%s:away", gameID)
+}
+
+// playerConnected records a connection. The first connection of a player
+// wins, so reconnecting on another tab does not reset their seniority.
+func (h *GameHub) playerConnected(ctx context.Context, gameID, playerID uuid.UUID) {
+	pipe := h.rdb.TxPipeline()
+	pipe.ZAddNX(ctx, GetRedisConnectedKey(gameID), redis.Z{Score: float64(time.Now().UnixMilli()), Member: playerID.String()})
+	pipe.ZRem(ctx, GetRedisAwayKey(gameID), playerID.String())
+	h.touchGame(ctx, pipe, gameID)
+	if _, err := pipe.Exec(ctx); err != nil {
+		h.logger.Error("Could not record connection", "gameId", gameID, "player", playerID, "err", err)
+	}
+}
+
+// playerDisconnected forgets a connection and gives the host the grace period
+// to come back if it was theirs.
+func (h *GameHub) playerDisconnected(ctx context.Context, gameID, playerID uuid.UUID) {
+	pipe := h.rdb.TxPipeline()
+	pipe.ZRem(ctx, GetRedisConnectedKey(gameID), playerID.String())
+	pipe.ZAdd(ctx, GetRedisAwayKey(gameID), redis.Z{Score: float64(time.Now().UnixMilli()), Member: playerID.String()})
+	if _, err := pipe.Exec(ctx); err != nil {
+		h.logger.Error("Could not record disconnection", "gameId", gameID, "player", playerID, "err", err)
+	}
+	h.scheduleHostMigration(gameID)
+}
+
+// scheduleHostMigration checks back after the grace period whether the host
+// is still away. Checking on every disconnect keeps it stateless: a host who
+// reconnected in the meantime is simply left alone. The timer only lives on
+// this node; the janitor sweep catches hosts it missed, see hostAwayTooLong.
+func (h *GameHub) scheduleHostMigration(gameID uuid.UUID) {
+	time.AfterFunc(h.config.HostGracePeriod, func() {
+		ctx := context.Background()
+		if err := h.migrateHost(ctx, gameID); err != nil && !errors.Is(err, ErrNoChange) {
+			h.logger.Error("Could not migrate host", "gameId", gameID, "err", err)
+		}
+	})
+}
+
+// pickNewHost returns the longest connected player that can take over from
+// the host. connected is ordered by connection time, oldest first.
+func pickNewHost(gs *dto.GameState, connected []string) (uuid.UUID, bool) {
+	for _, raw := range connected {
+		id, err := uuid.Parse(raw)
+		if err != nil || id == gs.HostID {
+			continue
+		}
+		if isInGame(gs, id) {
+			return id, true
+		}
+	}
+	return uuid.Nil, false
+}
+
+// hostAwayTooLong reports whether the host has had no connection for longer
+// than the grace period. A host without a recorded departure, e.g. one whose
+// node went down, is taken to have left now and gets the full grace period.
+func (h *GameHub) hostAwayTooLong(ctx context.Context, gameID uuid.UUID, gs *dto.GameState) (bool, error) {
+	err := h.rdb.ZScore(ctx, GetRedisConnectedKey(gameID), gs.HostID.String()).Err()
+	if !errors.Is(err, redis.Nil) {
+		return false, err
+	}
+
+	now := time.Now()
+	key := GetRedisAwayKey(gameID)
+	if err := h.rdb.ZAddNX(ctx, key, redis.Z{Score: float64(now.UnixMilli()), Member: gs.HostID.String()}).Err(); err != nil {
+		return false, err
+	}
+	since, err := h.rdb.ZScore(ctx, key, gs.HostID.String()).Result()
+	if err != nil {
+		return false, err
+	}
+	return now.Sub(time.UnixMilli(int64(since))) >= h.config.HostGracePeriod, nil
+}
+
+// migrateHost hands host rights to another connected player when the host
+// has no connection left. It is recorded like a transfer by the old host, so
+// replays reach the same state.
+func (h *GameHub) migrateHost(ctx context.Context, gameID uuid.UUID) error {
+	gs, err := h.GetGameState(ctx, gameID)
+	if errors.Is(err, ErrGameStateMissing) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if gs.Phase == dto.GamePhaseFinished {
+		return nil
+	}
+
+	key := GetRedisConnectedKey(gameID)
+	if err := h.rdb.ZScore(ctx, key, gs.HostID.String()).Err(); !errors.Is(err, redis.Nil) {
+		// Still connected, or Redis failed and the host gets the benefit of
+		// the doubt.
+		return err
+	}
+
+	connected, err := h.rdb.ZRange(ctx, key, 0, -1).Result()
+	if err != nil {
+		return err
+	}
+	newHost, ok := pickNewHost(&gs, connected)
+	if !ok {
+		return nil
+	}
+
+	oldHost, ok := gs.Player(gs.HostID)
+	if !ok {
+		oldHost = dto.GameStatePlayer{ID: gs.HostID}
+	}
+
+	g := h.GetGame(gameID)
+	if g == nil {
+		// Nobody is connected here, the game is only needed to publish.
+		g = NewGame(gameID, h)
+	}
+
+	h.logger.Info("Migrating host", "gameId", gameID, "from", oldHost.ID, "to", newHost)
+	// The expected version makes this a no-op if anything changed since the
+	// checks above, e.g. the host handed over rights themselves.
+	return g.apply(ctx, oldHost, TransferHostData{PlayerID: newHost}, &gs.Version)
+}
@@ -0,0 +1,55 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestPickNewHostPrefersLongestConnected(t *testing.T) {
+	gs, players := newTestGame(t)
+	stranger := uuid.New()
+
+	connected := []string{players[0].ID.String(), stranger.String(), players[3].ID.String(), players[1].ID.String()}
+	got, ok := pickNewHost(gs, connected)
+	if !ok || got != players[3].ID {
+		t.Errorf("expected the longest connected player in the game to be picked; got %s", got)
+	}
+
+	if _, ok := pickNewHost(gs, []string{players[0].ID.String(), stranger.String()}); ok {
+		t.Errorf("expected no candidate when only the host and strangers are connected")
+	}
+}
+
+func TestHostAwayTooLong(t *testing.T) {
+	h, gameID := newTestHub(t)
+	h.config.HostGracePeriod = time.Minute
+	ctx := context.Background()
+	gs, err := h.GetGameState(ctx, gameID)
+	if err != nil {
+		t.Fatalf("read game state: %v", err)
+	}
+	host := gs.HostID.String()
+
+	// Without a recorded departure the host gets the full grace period.
+	if away, err := h.hostAwayTooLong(ctx, gameID, &gs); err != nil || away {
+		t.Errorf("expected a host without a departure to be given time; got %v, %v", away, err)
+	}
+	if err := h.rdb.ZScore(ctx, GetRedisAwayKey(gameID), host).Err(); err != nil {
+		t.Errorf("expected the departure to be recorded; got %v", err)
+	}
+
+	left := time.Now().Add(-2 * time.Minute)
+	h.rdb.ZAdd(ctx, GetRedisAwayKey(gameID), redis.Z{Score: float64(left.UnixMilli()), Member: host})
+	if away, err := h.hostAwayTooLong(ctx, gameID, &gs); err != nil || !away {
+		t.Errorf("expected a host gone past the grace period to be away; got %v, %v", away, err)
+	}
+
+	h.rdb.ZAdd(ctx, GetRedisConnectedKey(gameID), redis.Z{Score: float64(time.Now().UnixMilli()), Member: host})
+	if away, err := h.hostAwayTooLong(ctx, gameID, &gs); err != nil || away {
+		t.Errorf("expected a connected host not to be away; got %v, %v", away, err)
+	}
+}
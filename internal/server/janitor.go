@@ -0,0 +1,312 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ninox14/gore-codenames/internal/database/dto"
+	"github.com/ninox14/gore-codenames/internal/database/sqlc"
+	"github.com/ninox14/gore-codenames/internal/env"
+	"github.com/redis/go-redis/v9"
+)
+
+// redisPresenceKey is a sorted set of game ids scored by the last time a node
+// saw a connected player or any activity in the game.
+const redisPresenceKey = "games:presence"
+
+// errGameActive stops archiving a game that saw activity after the deadline.
+var errGameActive = errors.New("game is active")
+
+type GameHubConfig struct {
+	// GameTTL is how long Redis keeps a game's keys after its last activity.
+	GameTTL time.Duration
+	// AbandonAfter is how long a game can go without connected players
+	// before the janitor archives it to Postgres.
+	AbandonAfter    time.Duration
+	JanitorInterval time.Duration
+	// SnapshotInterval is how often changed game states are written back to
+	// Postgres outside of transitions.
+	SnapshotInterval time.Duration
+	// HostGracePeriod is how long a disconnected host has to come back before
+	// another player is made host.
+	HostGracePeriod time.Duration
+}
+
+func DefaultGameHubConfig() GameHubConfig {
+	return GameHubConfig{
+		GameTTL:          24 * time.Hour,
+		AbandonAfter:     15 * time.Minute,
+		JanitorInterval:  time.Minute,
+		SnapshotInterval: 30 * time.Second,
+		HostGracePeriod:  time.Minute,
+	}
+}
+
+func GameHubConfigFromEnv() GameHubConfig {
+	def := DefaultGameHubConfig()
+	return GameHubConfig{
+		GameTTL:          env.GetDuration("GAME_TTL", def.GameTTL),
+		AbandonAfter:     env.GetDuration("GAME_ABANDON_AFTER", def.AbandonAfter),
+		JanitorInterval:  env.GetDuration("GAME_JANITOR_INTERVAL", def.JanitorInterval),
+		SnapshotInterval: env.GetDuration("GAME_SNAPSHOT_INTERVAL", def.SnapshotInterval),
+		HostGracePeriod:  env.GetDuration("GAME_HOST_GRACE_PERIOD", def.HostGracePeriod),
+	}
+}
+
+func getRedisJanitorLockKey(gameID uuid.UUID) string {
+	return fmt.Sprintf("game:%s:janitor", gameID)
+}
+
+// gameKeys lists every Redis key that belongs to a game.
+func gameKeys(gameID uuid.UUID) []string {
+	return []string{
+		GetRedisGameKey(gameID), GetRedisEventsKey(gameID), GetRedisChatKey(gameID),
+		GetRedisKickedKey(gameID), GetRedisConnectedKey(gameID), GetRedisAwayKey(gameID),
+	}
+}
+
+// touchGame queues a refresh of the game's key expiry and presence score on
+// pipe, so it can ride along with the write that caused the activity.
+func (h *GameHub) touchGame(ctx context.Context, pipe redis.Pipeliner, gameID uuid.UUID) {
+	for _, key := range gameKeys(gameID) {
+		pipe.Expire(ctx, key, h.config.GameTTL)
+	}
+	pipe.ZAdd(ctx, redisPresenceKey, redis.Z{Score: float64(time.Now().Unix()), Member: gameID.String()})
+}
+
+// TouchGame refreshes the game's expiry and presence outside of a write.
+func (h *GameHub) TouchGame(ctx context.Context, gameID uuid.UUID) error {
+	pipe := h.rdb.Pipeline()
+	h.touchGame(ctx, pipe, gameID)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// RunJanitor archives abandoned games until ctx is done. Every node runs one;
+// they coordinate through a per-game lock in Redis.
+func (h *GameHub) RunJanitor(ctx context.Context) {
+	ticker := time.NewTicker(h.config.JanitorInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			h.heartbeat(ctx)
+			if err := h.collectAbandonedGames(ctx); err != nil {
+				h.logger.Error("Janitor run failed", "err", err)
+			}
+			if err := h.sweepGames(ctx); err != nil {
+				h.logger.Error("Janitor sweep failed", "err", err)
+			}
+		}
+	}
+}
+
+// heartbeat marks games with players connected to this node as present.
+// The hub lock is released before any game is locked, see RemoveGame.
+func (h *GameHub) heartbeat(ctx context.Context) {
+	h.mu.RLock()
+	games := make([]*Game, 0, len(h.games))
+	for _, game := range h.games {
+		games = append(games, game)
+	}
+	h.mu.RUnlock()
+
+	ids := make([]uuid.UUID, 0, len(games))
+	for _, game := range games {
+		game.mu.RLock()
+		if len(game.Players) > 0 {
+			ids = append(ids, game.ID)
+		}
+		game.mu.RUnlock()
+	}
+
+	for _, id := range ids {
+		if err := h.TouchGame(ctx, id); err != nil {
+			h.logger.Error("Could not refresh game presence", "gameId", id, "err", err)
+		}
+	}
+}
+
+func (h *GameHub) collectAbandonedGames(ctx context.Context) error {
+	deadline := time.Now().Add(-h.config.AbandonAfter).Unix()
+
+	ids, err := h.rdb.ZRangeByScore(ctx, redisPresenceKey, &redis.ZRangeBy{
+		Min: "-inf",
+		Max: strconv.FormatInt(deadline, 10),
+	}).Result()
+	if err != nil {
+		return err
+	}
+
+	for _, raw := range ids {
+		gameID, err := uuid.Parse(raw)
+		if err != nil {
+			h.rdb.ZRem(ctx, redisPresenceKey, raw)
+			continue
+		}
+
+		if err := h.archiveGame(ctx, gameID, deadline); err != nil {
+			h.logger.Error("Could not archive abandoned game", "gameId", gameID, "err", err)
+		}
+	}
+	return nil
+}
+
+// sweepGames catches up on deadlines of active games whose timers were lost
+// to a restart or set on another node. Every node sweeps every game; the
+// actions only end what was due, so a second node applying them is a no-op.
+func (h *GameHub) sweepGames(ctx context.Context) error {
+	deadline := time.Now().Add(-h.config.AbandonAfter).Unix()
+
+	ids, err := h.rdb.ZRangeByScore(ctx, redisPresenceKey, &redis.ZRangeBy{
+		Min: "(" + strconv.FormatInt(deadline, 10),
+		Max: "+inf",
+	}).Result()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, raw := range ids {
+		gameID, err := uuid.Parse(raw)
+		if err != nil {
+			continue
+		}
+
+		gs, err := h.GetGameState(ctx, gameID)
+		if errors.Is(err, ErrGameStateMissing) {
+			continue
+		}
+		if err != nil {
+			h.logger.Error("Could not sweep game", "gameId", gameID, "err", err)
+			continue
+		}
+
+		if expiresAt, ok := overdueReadyCheck(&gs, now); ok {
+			err := h.expireReadyCheck(ctx, gameID, expiresAt)
+			if err != nil && !errors.Is(err, ErrNoReadyCheck) {
+				h.logger.Error("Could not expire ready check", "gameId", gameID, "err", err)
+			}
+		}
+
+		if gs.Phase == dto.GamePhaseFinished {
+			continue
+		}
+		away, err := h.hostAwayTooLong(ctx, gameID, &gs)
+		if err != nil {
+			h.logger.Error("Could not check on host", "gameId", gameID, "err", err)
+			continue
+		}
+		if away {
+			err := h.migrateHost(ctx, gameID)
+			if err != nil && !errors.Is(err, ErrNoChange) && !errors.Is(err, ErrStateConflict) {
+				h.logger.Error("Could not migrate host", "gameId", gameID, "err", err)
+			}
+		}
+	}
+	return nil
+}
+
+// releaseLockScript deletes a lock only while it still holds the token it was
+// taken with, so a lock that expired and was taken by another node is kept.
+var releaseLockScript = redis.NewScript(`
+	if redis.call("GET", KEYS[1]) == ARGV[1] then
+		return redis.call("DEL", KEYS[1])
+	end
+	return 0`)
+
+// archivedStatus is the status of an archived game: finished if it was played
+// to the end, abandoned otherwise.
+func archivedStatus(gs *dto.GameState) sqlc.GameStatus {
+	if gs != nil && gameStatusFor(gs.Phase) == sqlc.GameStatusFinished {
+		return sqlc.GameStatusFinished
+	}
+	return sqlc.GameStatusAbandoned
+}
+
+// archiveGame snapshots the game to Postgres and deletes its Redis keys,
+// unless another node holds the lock or the game saw activity meanwhile.
+//
+// The game and connected keys are watched, and the snapshot is only committed
+// once the keys are deleted, so a move, heartbeat or reconnect landing in
+// between aborts the whole archive.
+func (h *GameHub) archiveGame(ctx context.Context, gameID uuid.UUID, deadline int64) error {
+	lockKey, token := getRedisJanitorLockKey(gameID), uuid.NewString()
+	locked, err := h.rdb.SetNX(ctx, lockKey, token, h.config.JanitorInterval).Result()
+	if err != nil || !locked {
+		return err
+	}
+	defer releaseLockScript.Run(ctx, h.rdb, []string{lockKey}, token)
+
+	err = h.rdb.Watch(ctx, func(tx *redis.Tx) error {
+		score, err := tx.ZScore(ctx, redisPresenceKey, gameID.String()).Result()
+		if errors.Is(err, redis.Nil) || score > float64(deadline) {
+			return errGameActive
+		}
+		if err != nil {
+			return err
+		}
+
+		var gs *dto.GameState
+		raw, err := tx.JSONGet(ctx, GetRedisGameKey(gameID), "$").Result()
+		switch {
+		case errors.Is(err, redis.Nil) || (err == nil && raw == ""):
+			// The keys already expired, keep the last snapshot.
+		case err != nil:
+			return err
+		default:
+			state, err := decodeGameState(raw)
+			if err != nil {
+				return err
+			}
+			gs = &state
+		}
+
+		return h.db.WithTx(ctx, func(q *sqlc.Queries) error {
+			game, err := q.GetGameByID(ctx, gameID)
+			if err != nil {
+				return fmt.Errorf("could not load game: %w", err)
+			}
+			if gs == nil {
+				gs = game.GameState
+			}
+
+			status := archivedStatus(gs)
+			_, err = q.ArchiveGame(ctx, sqlc.ArchiveGameParams{ID: gameID, Status: status, GameState: gs})
+			if err != nil {
+				return fmt.Errorf("could not snapshot game: %w", err)
+			}
+			// Games the persister did not see finish are rated here.
+			if status == sqlc.GameStatusFinished && game.Status != sqlc.GameStatusFinished {
+				if err := updateRatings(ctx, q, gs); err != nil {
+					return fmt.Errorf("could not update ratings: %w", err)
+				}
+			}
+
+			_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+				pipe.Del(ctx, gameKeys(gameID)...)
+				pipe.ZRem(ctx, redisPresenceKey, gameID.String())
+				return nil
+			})
+			return err
+		})
+	}, GetRedisGameKey(gameID), GetRedisConnectedKey(gameID))
+
+	if errors.Is(err, errGameActive) || errors.Is(err, redis.TxFailedErr) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	h.RemoveGame(gameID)
+	h.logger.Info("Archived abandoned game", "gameId", gameID)
+	return nil
+}
@@ -0,0 +1,69 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/ninox14/gore-codenames/internal/database/sqlc"
+)
+
+const (
+	JoinCodeLength = 6
+	// joinCodeAlphabet leaves out 0/O and 1/I, which are easily confused
+	// when read out loud.
+	joinCodeAlphabet = "23456789ABCDEFGHJKLMNPQRSTUVWXYZ"
+	// joinCodeAttempts bounds retries when a generated code is taken.
+	joinCodeAttempts = 5
+)
+
+var ErrInvalidJoinCode = errors.New("invalid join code")
+
+func NewJoinCode() string {
+	buf := make([]byte, JoinCodeLength)
+	rand.Read(buf)
+
+	for i, b := range buf {
+		// The alphabet has 32 letters, so this keeps codes uniform.
+		buf[i] = joinCodeAlphabet[int(b)%len(joinCodeAlphabet)]
+	}
+	return string(buf)
+}
+
+// NormalizeJoinCode upper-cases a code typed by a player and checks it only
+// uses letters codes are made of.
+func NormalizeJoinCode(code string) (string, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if len(code) != JoinCodeLength {
+		return "", ErrInvalidJoinCode
+	}
+	for _, r := range code {
+		if !strings.ContainsRune(joinCodeAlphabet, r) {
+			return "", ErrInvalidJoinCode
+		}
+	}
+	return code, nil
+}
+
+func joinCodeText(code string) pgtype.Text {
+	return pgtype.Text{String: code, Valid: code != ""}
+}
+
+// isJoinCodeTaken reports whether err is a unique violation on the join code.
+func isJoinCodeTaken(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == "idx_games_join_code"
+}
+
+// ResolveJoinCode returns the game a code belongs to. Codes of archived games
+// are cleared, so only games that can still be joined resolve.
+func (h *GameHub) ResolveJoinCode(ctx context.Context, code string) (sqlc.Game, error) {
+	code, err := NormalizeJoinCode(code)
+	if err != nil {
+		return sqlc.Game{}, err
+	}
+	return h.db.Queries.GetGameByJoinCode(ctx, joinCodeText(code))
+}
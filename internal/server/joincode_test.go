@@ -0,0 +1,24 @@
+package server
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestJoinCodesAreUnambiguous(t *testing.T) {
+	for range 100 {
+		code := NewJoinCode()
+		if strings.ContainsAny(code, "0O1I") {
+			t.Fatalf("code %q contains an ambiguous character", code)
+		}
+		if got, err := NormalizeJoinCode(" " + strings.ToLower(code) + " "); err != nil || got != code {
+			t.Fatalf("expected %q to normalize to itself; got %q, %v", code, got, err)
+		}
+	}
+
+	for _, code := range []string{"", "ABCDE", "ABCDEFG", "ABCDE0", "ABC DE"} {
+		if _, err := NormalizeJoinCode(code); err == nil {
+			t.Errorf("expected %q to be rejected", code)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package server
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/ninox14/gore-codenames/internal/database/dto"
+	"github.com/ninox14/gore-codenames/internal/database/sqlc"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	DefaultLobbyPageSize = 20
+	MaxLobbyPageSize     = 100
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// LobbyCounts is how many players sit in each team and watch a game.
+type LobbyCounts struct {
+	Teams      map[dto.TeamColor]int `json:"teams"`
+	Spectators int                   `json:"spectators"`
+}
+
+type LobbyGame struct {
+	ID        uuid.UUID           `json:"id"`
+	Host      dto.GameStatePlayer `json:"host"`
+	Wordpack  LobbyWordpack       `json:"wordpack"`
+	Status    sqlc.GameStatus     `json:"status"`
+	CreatedAt time.Time           `json:"created_at"`
+	Players   LobbyCounts         `json:"players"`
+}
+
+type LobbyWordpack struct {
+	ID   int32  `json:"id"`
+	Name string `json:"name"`
+}
+
+// encodeLobbyCursor points after the given game in the lobby's order.
+func encodeLobbyCursor(createdAt time.Time, id uuid.UUID) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(createdAt.Format(time.RFC3339Nano) + "|" + id.String()))
+}
+
+func decodeLobbyCursor(cursor string) (time.Time, uuid.UUID, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, uuid.Nil, ErrInvalidCursor
+	}
+
+	ts, id, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return time.Time{}, uuid.Nil, ErrInvalidCursor
+	}
+	createdAt, err := time.Parse(time.RFC3339Nano, ts)
+	if err != nil {
+		return time.Time{}, uuid.Nil, ErrInvalidCursor
+	}
+	gameID, err := uuid.Parse(id)
+	if err != nil {
+		return time.Time{}, uuid.Nil, ErrInvalidCursor
+	}
+
+	return createdAt, gameID, nil
+}
+
+// LobbyCounts reads the seats of many games from Redis in one round trip.
+// Games without state are left out.
+func (h *GameHub) LobbyCounts(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]LobbyCounts, error) {
+	pipe := h.rdb.Pipeline()
+	cmds := make([]*redis.JSONCmd, len(ids))
+	for i, id := range ids {
+		cmds[i] = pipe.JSONGet(ctx, GetRedisGameKey(id), "$.spectators", "$.teams")
+	}
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return nil, err
+	}
+
+	counts := make(map[uuid.UUID]LobbyCounts, len(ids))
+	for i, cmd := range cmds {
+		raw, err := cmd.Result()
+		if err != nil || raw == "" {
+			continue
+		}
+
+		var seats struct {
+			Spectators [][]dto.GameStatePlayer      `json:"$.spectators"`
+			Teams      []map[dto.TeamColor]dto.Team `json:"$.teams"`
+		}
+		if err := json.Unmarshal([]byte(raw), &seats); err != nil {
+			h.logger.Error("Could not decode lobby seats", "gameId", ids[i], "err", err)
+			continue
+		}
+
+		c := LobbyCounts{Teams: make(map[dto.TeamColor]int)}
+		if len(seats.Spectators) > 0 {
+			c.Spectators = len(seats.Spectators[0])
+		}
+		if len(seats.Teams) > 0 {
+			for color, team := range seats.Teams[0] {
+				c.Teams[color] = len(team.Players)
+			}
+		}
+		counts[ids[i]] = c
+	}
+
+	return counts, nil
+}
+
+// ListLobbyGames lists public games that can still be joined, newest first.
+func (h *GameHub) ListLobbyGames(ctx context.Context, params sqlc.ListLobbyGamesParams) ([]LobbyGame, error) {
+	rows, err := h.db.Queries.ListLobbyGames(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]uuid.UUID, len(rows))
+	for i, row := range rows {
+		ids[i] = row.ID
+	}
+	counts, err := h.LobbyCounts(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	games := make([]LobbyGame, 0, len(rows))
+	for _, row := range rows {
+		c, ok := counts[row.ID]
+		if !ok {
+			// Archived by the janitor but not yet marked, or lost with Redis.
+			c = LobbyCounts{Teams: map[dto.TeamColor]int{}}
+		}
+
+		games = append(games, LobbyGame{
+			ID:        row.ID,
+			Host:      dto.GameStatePlayer{ID: row.HostID, Name: row.HostName},
+			Wordpack:  LobbyWordpack{ID: row.WordPackID, Name: row.WordpackName},
+			Status:    row.Status,
+			CreatedAt: row.CreatedAt.Time,
+			Players:   c,
+		})
+	}
+
+	return games, nil
+}
+
+func lobbyCursorParams(cursor string, params *sqlc.ListLobbyGamesParams) error {
+	if cursor == "" {
+		return nil
+	}
+
+	createdAt, id, err := decodeLobbyCursor(cursor)
+	if err != nil {
+		return err
+	}
+	params.CursorCreatedAt = pgtype.Timestamp{Time: createdAt, Valid: true}
+	params.CursorID = &id
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log/slog"
 	"net/http"
+	"net/url"
 	"strings"
 	"time"
 
@@ -33,7 +34,7 @@ func (s *Server) corsMW(next http.Handler) http.Handler {
 		// Set CORS headers
 		w.Header().Set("Access-Control-Allow-Origin", "*") // Replace "*" with specific origins if needed
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS, PATCH")
-		w.Header().Set("Access-Control-Allow-Headers", "Accept, Authorization, Content-Type, X-CSRF-Token")
+		w.Header().Set("Access-Control-Allow-Headers", "Accept, Authorization, Content-Type, Last-Event-ID, X-CSRF-Token")
 		w.Header().Set("Access-Control-Allow-Credentials", "false") // Set to "true" if credentials are required
 
 		// Handle preflight OPTIONS requests
@@ -60,6 +61,29 @@ func (s *Server) recoverPanicMW(next http.Handler) http.Handler {
 	})
 }
 
+// secretParams are query parameters that carry credentials.
+var secretParams = []string{"password", "invite", "token", "spectator_token"}
+
+// redactedURL returns the URL with credentials in the query blanked out, so
+// they do not end up in the logs.
+func redactedURL(u *url.URL) string {
+	query := u.Query()
+	redacted := false
+	for _, param := range secretParams {
+		if query.Has(param) {
+			query.Set(param, "REDACTED")
+			redacted = true
+		}
+	}
+	if !redacted {
+		return u.String()
+	}
+
+	clean := *u
+	clean.RawQuery = query.Encode()
+	return clean.String()
+}
+
 func (s *Server) logAccessMW(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		mw := response.NewMetricsResponseWriter(w)
@@ -68,7 +92,7 @@ func (s *Server) logAccessMW(next http.Handler) http.Handler {
 		var (
 			ip     = realip.FromRequest(r)
 			method = r.Method
-			url    = r.URL.String()
+			url    = redactedURL(r.URL)
 			proto  = r.Proto
 		)
 
@@ -0,0 +1,205 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/google/uuid"
+	"github.com/ninox14/gore-codenames/internal/database/dto"
+	"github.com/ninox14/gore-codenames/internal/database/sqlc"
+	"github.com/redis/go-redis/v9"
+)
+
+// KickCooldown is how long a kicked player has to wait before rejoining.
+const KickCooldown = 2 * time.Minute
+
+var (
+	ErrCannotTargetSelf = errors.New("the host cannot do that to themselves")
+	ErrPlayerNotInGame  = errors.New("player is not in the game")
+	ErrBanned           = errors.New("you are banned from this game")
+	ErrKicked           = errors.New("you were kicked from this game")
+)
+
+// GetRedisKickedKey is a hash of kicked user ids to the unix time they may
+// rejoin at.
+func GetRedisKickedKey(gameID uuid.UUID) string {
+	return fmt.Sprintf("game:%s:kicked", gameID)
+}
+
+func checkModeration(gs *dto.GameState, actor dto.GameStatePlayer, target uuid.UUID) error {
+	if gs.HostID != actor.ID {
+		return ErrNotHost
+	}
+	if target == actor.ID {
+		return ErrCannotTargetSelf
+	}
+	return nil
+}
+
+// KickPlayerData removes a player from the game. They can come back once
+// KickCooldown has passed.
+type KickPlayerData struct {
+	PlayerID uuid.UUID `json:"player_id"`
+}
+
+func (d KickPlayerData) Apply(gs *dto.GameState, actor dto.GameStatePlayer) error {
+	if err := checkModeration(gs, actor, d.PlayerID); err != nil {
+		return err
+	}
+	if !isInGame(gs, d.PlayerID) {
+		return ErrPlayerNotInGame
+	}
+
+	unseat(gs, d.PlayerID)
+	promoteWaitlist(gs)
+	return nil
+}
+
+// BanPlayerData removes a player, if present, and keeps the user from
+// rejoining the game.
+type BanPlayerData struct {
+	PlayerID uuid.UUID `json:"player_id"`
+}
+
+func (d BanPlayerData) Apply(gs *dto.GameState, actor dto.GameStatePlayer) error {
+	if err := checkModeration(gs, actor, d.PlayerID); err != nil {
+		return err
+	}
+	if gs.IsBanned(d.PlayerID) {
+		return ErrNoChange
+	}
+
+	player, ok := gs.Player(d.PlayerID)
+	if !ok {
+		player = dto.GameStatePlayer{ID: d.PlayerID}
+	}
+	unseat(gs, d.PlayerID)
+	promoteWaitlist(gs)
+	gs.Banned = append(gs.Banned, player)
+	return nil
+}
+
+// TransferHostData hands host rights to another player in the game.
+type TransferHostData struct {
+	PlayerID uuid.UUID `json:"player_id"`
+}
+
+func (d TransferHostData) Apply(gs *dto.GameState, actor dto.GameStatePlayer) error {
+	if err := checkModeration(gs, actor, d.PlayerID); err != nil {
+		return err
+	}
+	if !isInGame(gs, d.PlayerID) {
+		return ErrPlayerNotInGame
+	}
+
+	gs.HostID = d.PlayerID
+	return nil
+}
+
+// KickedData tells a player why their connection is about to be closed.
+type KickedData struct {
+	Reason string `json:"reason"`
+	Banned bool   `json:"banned"`
+}
+
+// Moderate applies a kick or ban and disconnects the target on whichever node
+// they are connected to.
+func (g *Game) Moderate(ctx context.Context, hostID uuid.UUID, action GameAction) {
+	if err := g.ApplyAction(ctx, hostID, action, nil); err != nil {
+		return
+	}
+
+	var data KickedData
+	var target uuid.UUID
+	switch a := action.(type) {
+	case KickPlayerData:
+		target = a.PlayerID
+		data.Reason = ErrKicked.Error()
+		if err := g.hub.recordKick(ctx, g.ID, target); err != nil {
+			g.hub.logger.Error("Could not record kick", "gameId", g.ID, "player", target, "err", err)
+		}
+	case BanPlayerData:
+		target = a.PlayerID
+		data.Reason = ErrBanned.Error()
+		data.Banned = true
+	default:
+		return
+	}
+
+	g.disconnect(ctx, Message{Type: MsgKicked, GameID: &g.ID, Data: data}, target)
+}
+
+// disconnect sends msg to the players and closes their sockets afterwards.
+func (g *Game) disconnect(ctx context.Context, msg Message, playerIDs ...uuid.UUID) {
+	g.publishEnvelope(ctx, msg, playerIDs, true)
+}
+
+// closePlayer drops a local player without broadcasting, as the state change
+// that caused it was already broadcast.
+func (g *Game) closePlayer(playerID uuid.UUID, reason string) {
+	g.mu.Lock()
+	player, exists := g.Players[playerID]
+	delete(g.Players, playerID)
+	empty := len(g.Players) == 0
+	g.mu.Unlock()
+
+	if !exists {
+		return
+	}
+	g.hub.playerDisconnected(context.Background(), g.ID, playerID)
+	if player.Client != nil {
+		player.Conn.Close(websocket.StatusPolicyViolation, reason)
+	}
+	if empty {
+		g.hub.RemoveGame(g.ID)
+	}
+}
+
+func (h *GameHub) recordKick(ctx context.Context, gameID, playerID uuid.UUID) error {
+	until := time.Now().Add(KickCooldown).Unix()
+
+	pipe := h.rdb.TxPipeline()
+	pipe.HSet(ctx, GetRedisKickedKey(gameID), playerID.String(), until)
+	h.touchGame(ctx, pipe, gameID)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// checkExcluded reports whether the user was banned from the game, or kicked
+// from it recently, before they are let back in.
+func (h *GameHub) checkExcluded(ctx context.Context, gameID, userID uuid.UUID) error {
+	gs, err := h.GetGameState(ctx, gameID)
+	switch {
+	case err == nil && gs.IsBanned(userID):
+		return ErrBanned
+	case err != nil && !errors.Is(err, ErrGameStateMissing):
+		return err
+	}
+	// A missing state is resumed by the join, which JoinAction checks too.
+
+	raw, err := h.rdb.HGet(ctx, GetRedisKickedKey(gameID), userID.String()).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	until, err := strconv.ParseInt(raw, 10, 64)
+	if err == nil && time.Now().Unix() < until {
+		return ErrKicked
+	}
+	return nil
+}
+
+// saveGameHost keeps games.host_id in line with the state's host, which HTTP
+// handlers check for host-only endpoints.
+func (h *GameHub) saveGameHost(ctx context.Context, gameID, hostID uuid.UUID) {
+	err := h.db.Queries.UpdateGameHost(ctx, sqlc.UpdateGameHostParams{ID: gameID, HostID: hostID})
+	if err != nil {
+		h.logger.Error("Could not update game host", "gameId", gameID, "host", hostID, "err", err)
+	}
+}
@@ -0,0 +1,178 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ninox14/gore-codenames/internal/database/dto"
+	"github.com/ninox14/gore-codenames/internal/database/sqlc"
+)
+
+// Snapshot metrics, served on /debug/vars.
+var (
+	snapshotMetrics        = expvar.NewMap("game_snapshots")
+	snapshotLastFailure    = new(expvar.String)
+	snapshotLastFailureAt  = new(expvar.String)
+	snapshotQueueOverflows = new(expvar.Int)
+)
+
+func init() {
+	snapshotMetrics.Set("last_failure", snapshotLastFailure)
+	snapshotMetrics.Set("last_failure_at", snapshotLastFailureAt)
+	snapshotMetrics.Set("queue_overflows", snapshotQueueOverflows)
+}
+
+const snapshotQueueSize = 256
+
+// persister writes game states from Redis back to Postgres. Games are marked
+// dirty on every change and written on the next tick, or right away when
+// they reach a transition.
+type persister struct {
+	hub   *GameHub
+	queue chan uuid.UUID
+
+	mu    sync.Mutex
+	dirty map[uuid.UUID]bool
+}
+
+func newPersister(hub *GameHub) *persister {
+	return &persister{hub: hub, queue: make(chan uuid.UUID, snapshotQueueSize), dirty: make(map[uuid.UUID]bool)}
+}
+
+func (p *persister) markDirty(gameID uuid.UUID) {
+	p.mu.Lock()
+	p.dirty[gameID] = true
+	p.mu.Unlock()
+}
+
+// persistNow queues an immediate snapshot. When the queue is full the game
+// stays dirty and is written on the next tick instead.
+func (p *persister) persistNow(gameID uuid.UUID) {
+	p.markDirty(gameID)
+
+	select {
+	case p.queue <- gameID:
+	default:
+		snapshotQueueOverflows.Add(1)
+	}
+}
+
+// RunPersister writes game states back to Postgres until ctx is done.
+func (h *GameHub) RunPersister(ctx context.Context) {
+	h.persister.run(ctx, h.config.SnapshotInterval)
+}
+
+// run snapshots queued games and, every interval, all dirty ones and the
+// queued game events until ctx is done.
+func (p *persister) run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case id := <-p.queue:
+			p.snapshot(ctx, id)
+		case <-ticker.C:
+			p.mu.Lock()
+			ids := make([]uuid.UUID, 0, len(p.dirty))
+			for id := range p.dirty {
+				ids = append(ids, id)
+			}
+			p.mu.Unlock()
+
+			for _, id := range ids {
+				p.snapshot(ctx, id)
+			}
+			if err := p.hub.writePendingEvents(ctx); err != nil {
+				p.hub.logger.Error("Could not write queued game events", "err", err)
+			}
+		}
+	}
+}
+
+func (p *persister) snapshot(ctx context.Context, gameID uuid.UUID) {
+	p.mu.Lock()
+	if !p.dirty[gameID] {
+		p.mu.Unlock()
+		return
+	}
+	delete(p.dirty, gameID)
+	p.mu.Unlock()
+
+	if err := p.hub.persistGameState(ctx, gameID); err != nil {
+		snapshotMetrics.Add("failed", 1)
+		snapshotLastFailure.Set(err.Error())
+		snapshotLastFailureAt.Set(time.Now().Format(time.RFC3339))
+		p.hub.logger.Error("Could not snapshot game state", "gameId", gameID, "err", err)
+
+		// The game was archived in the meantime, there is nothing to retry.
+		if !errors.Is(err, ErrGameStateMissing) {
+			p.markDirty(gameID)
+		}
+		return
+	}
+	snapshotMetrics.Add("ok", 1)
+}
+
+// gameStatusFor maps a game phase to the status stored in Postgres.
+func gameStatusFor(phase dto.GamePhase) sqlc.GameStatus {
+	switch phase {
+	case dto.GamePhasePlaying:
+		return sqlc.GameStatusStarted
+	case dto.GamePhaseFinished:
+		return sqlc.GameStatusFinished
+	default:
+		return sqlc.GameStatusInitial
+	}
+}
+
+// persistGameState copies the game state from Redis to its games row and
+// keeps the row's status in line with the game phase. Finishing a game also
+// updates the ratings of its players.
+func (h *GameHub) persistGameState(ctx context.Context, gameID uuid.UUID) error {
+	gs, err := h.GetGameState(ctx, gameID)
+	if err != nil {
+		return err
+	}
+
+	return h.db.WithTx(ctx, func(q *sqlc.Queries) error {
+		game, err := q.UpdateGameState(ctx, sqlc.UpdateGameStateParams{ID: gameID, GameState: &gs})
+		if err != nil {
+			return err
+		}
+
+		status := gameStatusFor(gs.Phase)
+		if game.Status == status {
+			return nil
+		}
+		_, err = q.UpdateGameStatus(ctx, sqlc.UpdateGameStatusParams{ID: gameID, Status: status})
+		if err != nil {
+			return err
+		}
+
+		// The status only changes to finished once, so every game is rated
+		// exactly once.
+		if status == sqlc.GameStatusFinished {
+			return updateRatings(ctx, q, &gs)
+		}
+		return nil
+	})
+}
+
+// isTransition reports whether the change from before to after is one that
+// is persisted right away: a game starting or finishing, or a turn ending.
+func isTransition(before, after *dto.GameState) bool {
+	if before.Phase != after.Phase {
+		return true
+	}
+	if before.Turn == nil || after.Turn == nil {
+		return before.Turn != after.Turn
+	}
+	return before.Turn.Team != after.Turn.Team
+}
@@ -0,0 +1,368 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/ninox14/gore-codenames/internal/database/dto"
+	"github.com/ninox14/gore-codenames/internal/jsonschema"
+)
+
+// ProtocolVersion is the newest websocket protocol version the server speaks.
+// Bump it when a message is removed or its payload changes incompatibly.
+const ProtocolVersion = 1
+
+// SupportedProtocolVersions lists every version a client can negotiate,
+// newest first.
+var SupportedProtocolVersions = []int{ProtocolVersion}
+
+const subprotocolPrefix = "codenames.v"
+
+var ErrUnknownMessageType = errors.New("unknown message type")
+
+// wsProtocol is what a connection negotiated: a protocol version and the codec
+// its frames are encoded with.
+type wsProtocol struct {
+	Version int
+	Codec   Codec
+}
+
+// Subprotocol returns the Sec-WebSocket-Protocol token for a version and
+// codec, e.g. "codenames.v1.msgpack".
+func Subprotocol(version int, codec Codec) string {
+	return subprotocolPrefix + strconv.Itoa(version) + "." + codec.Name()
+}
+
+func supportedSubprotocols() []string {
+	protocols := make([]string, 0, len(SupportedProtocolVersions)*(len(codecs)+1))
+	for _, v := range SupportedProtocolVersions {
+		protocols = append(protocols, subprotocolPrefix+strconv.Itoa(v))
+		for _, c := range codecs {
+			protocols = append(protocols, Subprotocol(v, c))
+		}
+	}
+	return protocols
+}
+
+// parseSubprotocol accepts "codenames.v<N>" (JSON) and "codenames.v<N>.<codec>".
+func parseSubprotocol(token string) (wsProtocol, bool) {
+	rest, ok := strings.CutPrefix(strings.ToLower(token), subprotocolPrefix)
+	if !ok {
+		return wsProtocol{}, false
+	}
+
+	versionStr, codecName, hasCodec := strings.Cut(rest, ".")
+	version, err := strconv.Atoi(versionStr)
+	if err != nil || !slices.Contains(SupportedProtocolVersions, version) {
+		return wsProtocol{}, false
+	}
+
+	codec := JSONCodec
+	if hasCodec {
+		if codec, ok = lookupCodec(codecName); !ok {
+			return wsProtocol{}, false
+		}
+	}
+
+	return wsProtocol{Version: version, Codec: codec}, true
+}
+
+// negotiateProtocol picks the newest version offered in the
+// Sec-WebSocket-Protocol header, honouring the client's order between codecs.
+// It also returns the token to echo back. Clients that offer nothing get the
+// current version over JSON so existing frontends keep working.
+func negotiateProtocol(r *http.Request) (wsProtocol, string, error) {
+	offered := r.Header.Values("Sec-WebSocket-Protocol")
+	if len(offered) == 0 {
+		return wsProtocol{Version: ProtocolVersion, Codec: JSONCodec}, "", nil
+	}
+
+	var (
+		best      wsProtocol
+		bestToken string
+	)
+	for _, h := range offered {
+		for token := range strings.SplitSeq(h, ",") {
+			token = strings.TrimSpace(token)
+			p, ok := parseSubprotocol(token)
+			if ok && p.Version > best.Version {
+				best, bestToken = p, token
+			}
+		}
+	}
+
+	if bestToken == "" {
+		return wsProtocol{}, "", fmt.Errorf("unsupported protocol, expected one of %s", strings.Join(supportedSubprotocols(), ", "))
+	}
+
+	return best, bestToken, nil
+}
+
+type MessageType string
+
+const (
+	MsgHello    MessageType = "hello"
+	MsgJoinGame MessageType = "join_game"
+	// MsgLeaveGame is only recorded in event logs, for LeaveAction.
+	MsgLeaveGame  MessageType = "leave_game"
+	MsgGameState  MessageType = "game_state"
+	MsgChangeTeam MessageType = "change_team"
+	// MsgPlayerJoined MessageType = "player_joined"
+	// MsgPlayerLeft   MessageType = "player_left"
+	MsgError MessageType = "error"
+
+	MsgChatMessage MessageType = "chat_message"
+	MsgChatHistory MessageType = "chat_history"
+
+	MsgSetCaptain MessageType = "set_captain"
+	MsgStartGame  MessageType = "start_game"
+	MsgGiveClue   MessageType = "give_clue"
+	MsgGuessCard  MessageType = "guess_card"
+	MsgEndTurn    MessageType = "end_turn"
+
+	MsgKickPlayer   MessageType = "kick_player"
+	MsgBanPlayer    MessageType = "ban_player"
+	MsgTransferHost MessageType = "transfer_host"
+	MsgKicked       MessageType = "kicked"
+	MsgHostChanged  MessageType = "host_changed"
+
+	MsgBalanceTeams MessageType = "balance_teams"
+	MsgShuffleTeams MessageType = "shuffle_teams"
+	MsgWaitlist     MessageType = "waitlist"
+
+	MsgSetReady         MessageType = "set_ready"
+	MsgStartReadyCheck  MessageType = "start_ready_check"
+	MsgCancelReadyCheck MessageType = "cancel_ready_check"
+)
+
+type MessageDirection string
+
+const (
+	ClientMessage MessageDirection = "client"
+	ServerMessage MessageDirection = "server"
+)
+
+// MessageSpec describes one message of the websocket protocol. Payload is the
+// type of Message.Data, or nil when the message carries no data.
+type MessageSpec struct {
+	Type        MessageType
+	Direction   MessageDirection
+	Description string
+	Since       int
+	Class       MessageClass
+	Payload     reflect.Type
+}
+
+func (s MessageSpec) decode(data []byte) (any, error) {
+	if s.Payload == nil {
+		return nil, nil
+	}
+
+	v := reflect.New(s.Payload)
+	if err := json.Unmarshal(data, v.Interface()); err != nil {
+		return nil, err
+	}
+	return v.Elem().Interface(), nil
+}
+
+func clientMessage[T any](t MessageType, class MessageClass, description string) MessageSpec {
+	return MessageSpec{Type: t, Direction: ClientMessage, Description: description, Since: 1, Class: class, Payload: reflect.TypeFor[T]()}
+}
+
+func serverMessage[T any](t MessageType, description string) MessageSpec {
+	return MessageSpec{Type: t, Direction: ServerMessage, Description: description, Since: 1, Payload: reflect.TypeFor[T]()}
+}
+
+type NoData struct{}
+
+type HelloData struct {
+	ProtocolVersion   int   `json:"protocol_version"`
+	SupportedVersions []int `json:"supported_versions"`
+}
+
+// JoinGameData lets players join with a room code instead of game_id.
+type JoinGameData struct {
+	Code string `json:"code,omitempty"`
+}
+
+type ChangeTeamData struct {
+	Destination RedisPlayersPath `json:"destination"`
+}
+
+type ErrorData struct {
+	Message string `json:"message"`
+	Err     string `json:"err"`
+}
+
+var messageRegistry = map[MessageDirection]map[MessageType]MessageSpec{}
+
+func registerMessages(specs ...MessageSpec) {
+	for _, spec := range specs {
+		if spec.Payload == reflect.TypeFor[NoData]() {
+			spec.Payload = nil
+		}
+		if messageRegistry[spec.Direction] == nil {
+			messageRegistry[spec.Direction] = make(map[MessageType]MessageSpec)
+		}
+		messageRegistry[spec.Direction][spec.Type] = spec
+	}
+}
+
+func init() {
+	registerMessages(
+		clientMessage[JoinGameData](MsgJoinGame, ClassLobby, "Join the game given in game_id, or by its room code, as a spectator."),
+		clientMessage[ChangeTeamData](MsgChangeTeam, ClassLobby, "Move the sender to a team or back to spectators."),
+		clientMessage[ChatMessageData](MsgChatMessage, ClassChat, "Post a chat message to everyone, the sender's team or the spectators."),
+		clientMessage[SetCaptainData](MsgSetCaptain, ClassLobby, "Make the sender, or as host any seated player, their team's spymaster."),
+		clientMessage[StartGameData](MsgStartGame, ClassLobby, "Start the game. Host only."),
+		clientMessage[GiveClueData](MsgGiveClue, ClassGame, "Give the clue for the current turn. Spymaster only."),
+		clientMessage[GuessCardData](MsgGuessCard, ClassGame, "Reveal a card as an operative of the playing team."),
+		clientMessage[EndTurnData](MsgEndTurn, ClassGame, "Stop guessing and pass the turn."),
+		clientMessage[KickPlayerData](MsgKickPlayer, ClassLobby, "Remove a player from the game for a while. Host only."),
+		clientMessage[BanPlayerData](MsgBanPlayer, ClassLobby, "Remove a user from the game for good. Host only."),
+		clientMessage[TransferHostData](MsgTransferHost, ClassLobby, "Hand host rights to another player in the game. Host only."),
+		clientMessage[BalanceTeamsData](MsgBalanceTeams, ClassLobby, "Even out team sizes by moving operatives. Host only."),
+		clientMessage[ShuffleTeamsData](MsgShuffleTeams, ClassLobby, "Deal every seated player into new teams, evening out sizes or ratings. Host only."),
+		clientMessage[SetReadyData](MsgSetReady, ClassLobby, "Mark the sender, who has to be on a team, ready or not."),
+		clientMessage[StartReadyCheckData](MsgStartReadyCheck, ClassLobby, "Ask every seated player to get ready within seconds, optionally starting once all are. Host only."),
+		clientMessage[CancelReadyCheckData](MsgCancelReadyCheck, ClassLobby, "End the running ready check. Host only."),
+
+		serverMessage[HelloData](MsgHello, "Sent once after connecting with the negotiated protocol version."),
+		serverMessage[dto.GameState](MsgGameState, "Full game state, sent after every change."),
+		serverMessage[ErrorData](MsgError, "A request could not be processed."),
+		serverMessage[ChatEntry](MsgChatMessage, "A chat message visible to the receiver."),
+		serverMessage[ChatHistoryData](MsgChatHistory, "Recent chat messages, sent after joining."),
+		serverMessage[HostChangedData](MsgHostChanged, "Host rights moved to another player, by hand or because the host left."),
+		serverMessage[WaitlistData](MsgWaitlist, "Sent instead of the game state while the receiver waits for a spectator seat."),
+		serverMessage[KickedData](MsgKicked, "The receiver was removed by the host. The socket is closed next."),
+	)
+}
+
+func LookupMessage(direction MessageDirection, t MessageType) (MessageSpec, bool) {
+	spec, ok := messageRegistry[direction][t]
+	return spec, ok
+}
+
+// Messages returns the registered messages for a direction sorted by type.
+func Messages(direction MessageDirection) []MessageSpec {
+	specs := make([]MessageSpec, 0, len(messageRegistry[direction]))
+	for _, spec := range messageRegistry[direction] {
+		specs = append(specs, spec)
+	}
+	slices.SortFunc(specs, func(a, b MessageSpec) int { return strings.Compare(string(a.Type), string(b.Type)) })
+	return specs
+}
+
+type Message struct {
+	Type   MessageType `json:"type"`
+	Data   any         `json:"data"`
+	GameID *uuid.UUID  `json:"game_id,omitempty"`
+	// Version is the game state version a client acted on. Actions sent with
+	// a stale version are rejected with a conflict instead of being applied.
+	Version *int64 `json:"version,omitempty"`
+}
+
+// UnmarshalJSON decodes a client message, using the registry to pick the
+// payload type for Data.
+func (m *Message) UnmarshalJSON(data []byte) error {
+	// First unmarshal into a temporary struct to get the type
+	var temp struct {
+		Type    MessageType     `json:"type"`
+		Data    json.RawMessage `json:"data"`
+		GameID  *uuid.UUID      `json:"game_id,omitempty"`
+		Version *int64          `json:"version,omitempty"`
+	}
+
+	if err := json.Unmarshal(data, &temp); err != nil {
+		return err
+	}
+
+	spec, ok := LookupMessage(ClientMessage, temp.Type)
+	if !ok {
+		return fmt.Errorf("%w %q", ErrUnknownMessageType, temp.Type)
+	}
+
+	m.Type = temp.Type
+	m.GameID = temp.GameID
+	m.Version = temp.Version
+	m.Data = nil
+
+	if len(temp.Data) == 0 || string(temp.Data) == "null" {
+		// Payloads without required fields may be omitted.
+		if spec.Payload != nil {
+			m.Data = reflect.Zero(spec.Payload).Interface()
+		}
+		return nil
+	}
+
+	payload, err := spec.decode(temp.Data)
+	if err != nil {
+		return fmt.Errorf("invalid data for %s: %w", temp.Type, err)
+	}
+	m.Data = payload
+
+	return nil
+}
+
+// ProtocolSchema builds a JSON Schema document for every registered message of
+// the given protocol version. Clients validate against #/$defs/ClientMessage
+// and #/$defs/ServerMessage.
+func ProtocolSchema(version int) *jsonschema.Schema {
+	r := jsonschema.NewReflector()
+
+	doc := &jsonschema.Schema{
+		Schema:      jsonschema.Draft,
+		ID:          fmt.Sprintf("urn:gore-codenames:protocol:v%d", version),
+		Title:       fmt.Sprintf("Codenames websocket protocol %s%d", subprotocolPrefix, version),
+		Description: "Generated by cmd/protoschema. Do not edit. Frames are JSON or MessagePack depending on the negotiated subprotocol; both encodings share this schema.",
+	}
+
+	roots := map[MessageDirection]string{ClientMessage: "ClientMessage", ServerMessage: "ServerMessage"}
+	for _, direction := range []MessageDirection{ClientMessage, ServerMessage} {
+		root := &jsonschema.Schema{OneOf: []*jsonschema.Schema{}}
+
+		for _, spec := range Messages(direction) {
+			if spec.Since > version {
+				continue
+			}
+
+			msg := &jsonschema.Schema{
+				Type:        "object",
+				Description: spec.Description,
+				Properties: map[string]*jsonschema.Schema{
+					"type":    {Const: spec.Type},
+					"game_id": r.Reflect(reflect.TypeFor[uuid.UUID]()),
+					"version": {Type: "integer"},
+				},
+				Required: []string{"type"},
+			}
+			if spec.Payload != nil {
+				data := r.Reflect(spec.Payload)
+				msg.Properties["data"] = data
+
+				// Payloads without required fields may be omitted.
+				if def, ok := r.Defs[strings.TrimPrefix(data.Ref, "#/$defs/")]; !ok || len(def.Required) > 0 {
+					msg.Required = append(msg.Required, "data")
+				}
+			}
+
+			name := fmt.Sprintf("%s.%s", direction, spec.Type)
+			r.Defs[name] = msg
+			root.OneOf = append(root.OneOf, jsonschema.Ref(name))
+		}
+
+		r.Defs[roots[direction]] = root
+	}
+
+	doc.AnyOf = []*jsonschema.Schema{jsonschema.Ref(roots[ClientMessage]), jsonschema.Ref(roots[ServerMessage])}
+	doc.Defs = r.Defs
+
+	return doc
+}
@@ -0,0 +1,136 @@
+package server
+
+import (
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/time/rate"
+)
+
+// MessageClass groups client messages that share a rate limit budget.
+type MessageClass string
+
+const (
+	// ClassLobby covers joins and seat changes, each of which runs a Redis
+	// script and a full-state broadcast.
+	ClassLobby MessageClass = "lobby"
+	ClassChat  MessageClass = "chat"
+	ClassGame  MessageClass = "game"
+)
+
+type rateBudget struct {
+	Every time.Duration
+	Burst int
+}
+
+// connectionBudgets apply to a single socket. A user's sockets additionally
+// share userBudgets, so opening more tabs does not multiply the allowance.
+var (
+	connectionBudgets = map[MessageClass]rateBudget{
+		ClassLobby: {Every: time.Second, Burst: 5},
+		ClassChat:  {Every: 500 * time.Millisecond, Burst: 8},
+		ClassGame:  {Every: 250 * time.Millisecond, Burst: 10},
+	}
+	userBudgets = map[MessageClass]rateBudget{
+		ClassLobby: {Every: 500 * time.Millisecond, Burst: 10},
+		ClassChat:  {Every: 250 * time.Millisecond, Burst: 12},
+		ClassGame:  {Every: 125 * time.Millisecond, Burst: 20},
+	}
+
+	// abuseBudget is consumed by every rejected or malformed message. Once it
+	// runs dry the socket is closed with a policy violation.
+	abuseBudget = rateBudget{Every: 2 * time.Second, Burst: 20}
+)
+
+func newLimiters(budgets map[MessageClass]rateBudget) map[MessageClass]*rate.Limiter {
+	limiters := make(map[MessageClass]*rate.Limiter, len(budgets))
+	for class, b := range budgets {
+		limiters[class] = rate.NewLimiter(rate.Every(b.Every), b.Burst)
+	}
+	return limiters
+}
+
+type userLimiter struct {
+	limiters map[MessageClass]*rate.Limiter
+	conns    int
+}
+
+// connLimiter throttles the messages read from one websocket connection.
+type connLimiter struct {
+	conn  map[MessageClass]*rate.Limiter
+	user  map[MessageClass]*rate.Limiter
+	abuse *rate.Limiter
+}
+
+// Allow reports whether a message of the given class may be processed now.
+// Tokens are only taken from the user budget when the connection budget
+// allows the message.
+func (l *connLimiter) Allow(class MessageClass) bool {
+	now := time.Now()
+	connLimit, userLimit := l.conn[class], l.user[class]
+	if connLimit == nil || userLimit == nil {
+		return true
+	}
+
+	connRes := connLimit.ReserveN(now, 1)
+	if !connRes.OK() || connRes.DelayFrom(now) > 0 {
+		connRes.CancelAt(now)
+		return false
+	}
+
+	userRes := userLimit.ReserveN(now, 1)
+	if !userRes.OK() || userRes.DelayFrom(now) > 0 {
+		userRes.CancelAt(now)
+		connRes.CancelAt(now)
+		return false
+	}
+	return true
+}
+
+// Violation records a rejected message and reports whether the client has
+// exceeded the sustained abuse threshold.
+func (l *connLimiter) Violation() bool {
+	return !l.abuse.Allow()
+}
+
+type limiterRegistry struct {
+	users map[uuid.UUID]*userLimiter
+	mu    sync.Mutex
+}
+
+func newLimiterRegistry() *limiterRegistry {
+	return &limiterRegistry{users: make(map[uuid.UUID]*userLimiter)}
+}
+
+// Acquire returns a limiter for a new connection of userID. Every call must
+// be paired with Release once the connection closes.
+func (r *limiterRegistry) Acquire(userID uuid.UUID) *connLimiter {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ul, ok := r.users[userID]
+	if !ok {
+		ul = &userLimiter{limiters: newLimiters(userBudgets)}
+		r.users[userID] = ul
+	}
+	ul.conns++
+
+	return &connLimiter{
+		conn:  newLimiters(connectionBudgets),
+		user:  ul.limiters,
+		abuse: rate.NewLimiter(rate.Every(abuseBudget.Every), abuseBudget.Burst),
+	}
+}
+
+func (r *limiterRegistry) Release(userID uuid.UUID) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if ul, ok := r.users[userID]; ok {
+		ul.conns--
+		if ul.conns <= 0 {
+			delete(r.users, userID)
+		}
+	}
+}
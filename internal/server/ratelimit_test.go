@@ -0,0 +1,51 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestLimiterSharesUserBudget(t *testing.T) {
+	reg := newLimiterRegistry()
+	userID := uuid.New()
+
+	first := reg.Acquire(userID)
+	second := reg.Acquire(userID)
+
+	allowed := 0
+	for range connectionBudgets[ClassLobby].Burst {
+		if first.Allow(ClassLobby) {
+			allowed++
+		}
+		if second.Allow(ClassLobby) {
+			allowed++
+		}
+	}
+
+	if want := userBudgets[ClassLobby].Burst; allowed != want {
+		t.Errorf("expected %d messages across connections; got %d", want, allowed)
+	}
+	if !first.Allow(ClassChat) {
+		t.Errorf("expected chat budget to be independent of lobby budget")
+	}
+
+	reg.Release(userID)
+	reg.Release(userID)
+	if len(reg.users) != 0 {
+		t.Errorf("expected user limiter to be released; got %d", len(reg.users))
+	}
+}
+
+func TestLimiterViolation(t *testing.T) {
+	l := newLimiterRegistry().Acquire(uuid.New())
+
+	for range abuseBudget.Burst {
+		if l.Violation() {
+			t.Fatalf("expected burst of violations to be tolerated")
+		}
+	}
+	if !l.Violation() {
+		t.Errorf("expected sustained violations to be reported")
+	}
+}
@@ -0,0 +1,80 @@
+package server
+
+import (
+	"context"
+	"math"
+
+	"github.com/google/uuid"
+	"github.com/ninox14/gore-codenames/internal/database/dto"
+	"github.com/ninox14/gore-codenames/internal/database/sqlc"
+)
+
+// RatingK is the most a single game moves a rating.
+const RatingK = 32
+
+// rateGame works out new Elo ratings for everyone seated in a finished game.
+// Teams play as one player rated at the mean of their members, against the
+// mean of everyone else. Games without a winner change nothing.
+func rateGame(gs *dto.GameState, ratings map[uuid.UUID]int) map[uuid.UUID]int {
+	if gs.Phase != dto.GamePhaseFinished || gs.Winner == nil {
+		return nil
+	}
+
+	rating := func(id uuid.UUID) int {
+		if r, ok := ratings[id]; ok {
+			return r
+		}
+		return DefaultRating
+	}
+
+	total, seated := 0, 0
+	for _, team := range gs.Teams {
+		for _, p := range team.Players {
+			total += rating(p.ID)
+			seated++
+		}
+	}
+
+	updated := make(map[uuid.UUID]int, seated)
+	for color, team := range gs.Teams {
+		n := len(team.Players)
+		if n == 0 || n == seated {
+			continue
+		}
+
+		teamTotal := 0
+		for _, p := range team.Players {
+			teamTotal += rating(p.ID)
+		}
+		own := float64(teamTotal) / float64(n)
+		others := float64(total-teamTotal) / float64(seated-n)
+
+		expected := 1 / (1 + math.Pow(10, (others-own)/400))
+		score := 0.0
+		if color == *gs.Winner {
+			score = 1
+		}
+		delta := int(math.Round(RatingK * (score - expected)))
+
+		for _, p := range team.Players {
+			updated[p.ID] = rating(p.ID) + delta
+		}
+	}
+	return updated
+}
+
+// updateRatings stores the ratings players earned in a finished game.
+func updateRatings(ctx context.Context, q *sqlc.Queries, gs *dto.GameState) error {
+	ratings, err := listRatings(ctx, q, seatedPlayers(gs))
+	if err != nil {
+		return err
+	}
+
+	for id, rating := range rateGame(gs, ratings) {
+		err := q.UpsertPlayerRating(ctx, sqlc.UpsertPlayerRatingParams{UserID: id, Rating: int32(rating)})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
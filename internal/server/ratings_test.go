@@ -0,0 +1,34 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/ninox14/gore-codenames/internal/database/dto"
+)
+
+func TestRateGame(t *testing.T) {
+	gs, players := newTestGame(t)
+	gs.Phase = dto.GamePhaseFinished
+	winner := dto.TeamColorBlue
+	gs.Winner = &winner
+
+	// Red players 0 and 1 were favoured and lost.
+	ratings := map[uuid.UUID]int{players[0].ID: 1200, players[1].ID: 1200}
+	updated := rateGame(gs, ratings)
+
+	if len(updated) != len(players) {
+		t.Fatalf("expected every seated player to be rated; got %d", len(updated))
+	}
+	if updated[players[0].ID] >= 1200 || updated[players[2].ID] <= DefaultRating {
+		t.Errorf("expected ratings to move from losers to winners; got %v", updated)
+	}
+	if gain := updated[players[2].ID] - DefaultRating; gain <= RatingK/2 || gain > RatingK {
+		t.Errorf("expected an upset to gain more than half of %d; got %d", RatingK, gain)
+	}
+
+	gs.Winner = nil
+	if updated := rateGame(gs, ratings); len(updated) != 0 {
+		t.Errorf("expected a game without a winner to change nothing; got %v", updated)
+	}
+}
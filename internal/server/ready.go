@@ -0,0 +1,184 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ninox14/gore-codenames/internal/database/dto"
+)
+
+const (
+	DefaultReadyCheckSeconds = 30
+	MinReadyCheckSeconds     = 5
+	MaxReadyCheckSeconds     = 300
+)
+
+var (
+	ErrInvalidReadyCheck   = errors.New("ready checks last between 5 and 300 seconds")
+	ErrNoReadyCheck        = errors.New("there is no ready check running")
+	ErrReadyCheckUnstamped = errors.New("ready check has no expiry")
+)
+
+// setReady updates the flag on the player's seat, as teams hold copies.
+func setReady(gs *dto.GameState, playerID uuid.UUID, ready bool) bool {
+	for _, team := range gs.Teams {
+		for i := range team.Players {
+			if team.Players[i].ID == playerID {
+				team.Players[i].Ready = ready
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func allReady(gs *dto.GameState) bool {
+	for _, team := range gs.Teams {
+		for _, p := range team.Players {
+			if !p.Ready {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// SetReadyData marks the sender ready or not. Once everyone seated is ready
+// during a ready check with AutoStart, the game starts.
+type SetReadyData struct {
+	Ready bool `json:"ready"`
+}
+
+func (d SetReadyData) Apply(gs *dto.GameState, actor dto.GameStatePlayer) error {
+	if gs.Phase != dto.GamePhaseLobby {
+		return ErrNotInLobby
+	}
+	seat, seated := gs.Player(actor.ID)
+	if _, onTeam := gs.TeamOf(actor.ID); !seated || !onTeam {
+		return ErrNotSeated
+	}
+	if seat.Ready == d.Ready {
+		return ErrNoChange
+	}
+	setReady(gs, actor.ID, d.Ready)
+
+	if gs.ReadyCheck != nil && gs.ReadyCheck.AutoStart && allReady(gs) {
+		// Teams without a captain or enough players keep the lobby open,
+		// the host can still start by hand once that is sorted.
+		_ = startGame(gs)
+	}
+	return nil
+}
+
+// StartReadyCheckData asks every seated player to confirm within Seconds.
+// ExpiresAt is set by the server when the check is received and kept so
+// replays do not depend on the clock. Host only.
+type StartReadyCheckData struct {
+	Seconds   int        `json:"seconds,omitempty"`
+	AutoStart bool       `json:"auto_start"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+func (d StartReadyCheckData) Apply(gs *dto.GameState, actor dto.GameStatePlayer) error {
+	if gs.HostID != actor.ID {
+		return ErrNotHost
+	}
+	if gs.Phase != dto.GamePhaseLobby {
+		return ErrNotInLobby
+	}
+	if d.Seconds < MinReadyCheckSeconds || d.Seconds > MaxReadyCheckSeconds {
+		return ErrInvalidReadyCheck
+	}
+	if d.ExpiresAt == nil {
+		return ErrReadyCheckUnstamped
+	}
+
+	for _, team := range gs.Teams {
+		for i := range team.Players {
+			team.Players[i].Ready = false
+		}
+	}
+	gs.ReadyCheck = &dto.ReadyCheck{ExpiresAt: *d.ExpiresAt, AutoStart: d.AutoStart}
+	return nil
+}
+
+// CancelReadyCheckData ends the running ready check. The hub sends it on
+// behalf of the host once the check expires, naming the check it scheduled
+// so a newer one is left running. Host only.
+type CancelReadyCheckData struct {
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+func (d CancelReadyCheckData) Apply(gs *dto.GameState, actor dto.GameStatePlayer) error {
+	if gs.HostID != actor.ID {
+		return ErrNotHost
+	}
+	if gs.ReadyCheck == nil {
+		return ErrNoReadyCheck
+	}
+	if d.ExpiresAt != nil && !gs.ReadyCheck.ExpiresAt.Equal(*d.ExpiresAt) {
+		return ErrNoChange
+	}
+
+	gs.ReadyCheck = nil
+	return nil
+}
+
+// StartReadyCheck stamps the expiry on the check and ends it once that passes.
+// The timer only lives on this node; the janitor sweep ends checks it missed.
+// Ready flags are kept after expiry, so the host can see who did not answer.
+func (g *Game) StartReadyCheck(ctx context.Context, playerID uuid.UUID, data StartReadyCheckData, expectedVersion *int64) {
+	if data.Seconds == 0 {
+		data.Seconds = DefaultReadyCheckSeconds
+	}
+	expiresAt := time.Now().Add(time.Duration(data.Seconds) * time.Second).UTC().Truncate(time.Millisecond)
+	data.ExpiresAt = &expiresAt
+	if err := g.ApplyAction(ctx, playerID, data, expectedVersion); err != nil {
+		return
+	}
+
+	gameID, hub := g.ID, g.hub
+	time.AfterFunc(time.Until(expiresAt), func() {
+		if err := hub.expireReadyCheck(context.Background(), gameID, expiresAt); err != nil {
+			hub.logger.Error("Could not expire ready check", "gameId", gameID, "err", err)
+		}
+	})
+}
+
+// overdueReadyCheck returns the expiry of the running ready check if it has
+// passed by now.
+func overdueReadyCheck(gs *dto.GameState, now time.Time) (time.Time, bool) {
+	if gs.ReadyCheck == nil || now.Before(gs.ReadyCheck.ExpiresAt) {
+		return time.Time{}, false
+	}
+	return gs.ReadyCheck.ExpiresAt, true
+}
+
+// expireReadyCheck ends the check that expires at expiresAt, if it is still
+// running. Like host migration, it is recorded as done by the host.
+func (h *GameHub) expireReadyCheck(ctx context.Context, gameID uuid.UUID, expiresAt time.Time) error {
+	gs, err := h.GetGameState(ctx, gameID)
+	if errors.Is(err, ErrGameStateMissing) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if gs.ReadyCheck == nil || !gs.ReadyCheck.ExpiresAt.Equal(expiresAt) {
+		return nil
+	}
+
+	host, ok := gs.Player(gs.HostID)
+	if !ok {
+		host = dto.GameStatePlayer{ID: gs.HostID}
+	}
+
+	g := h.GetGame(gameID)
+	if g == nil {
+		g = NewGame(gameID, h)
+	}
+	// No expected version, the action itself only ends this very check.
+	return g.apply(ctx, host, CancelReadyCheckData{ExpiresAt: &expiresAt}, nil)
+}
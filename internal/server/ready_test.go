@@ -0,0 +1,77 @@
+package server
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ninox14/gore-codenames/internal/database/dto"
+)
+
+func TestReadyCheckAutoStart(t *testing.T) {
+	gs, players := newTestGame(t)
+	gs.Phase = dto.GamePhaseLobby
+	gs.Turn = nil
+	host := players[0]
+
+	expiresAt := time.Now().Add(time.Minute)
+	check := StartReadyCheckData{Seconds: 60, AutoStart: true, ExpiresAt: &expiresAt}
+	if err := check.Apply(gs, players[1]); !errors.Is(err, ErrNotHost) {
+		t.Fatalf("expected ready check by non-host to be rejected; got %v", err)
+	}
+	if err := check.Apply(gs, host); err != nil {
+		t.Fatalf("start ready check: %v", err)
+	}
+
+	for i, p := range players {
+		if err := (SetReadyData{Ready: true}).Apply(gs, p); err != nil {
+			t.Fatalf("ready: %v", err)
+		}
+		if i < len(players)-1 && gs.Phase != dto.GamePhaseLobby {
+			t.Fatalf("expected the game to wait for every player")
+		}
+	}
+	if gs.Phase != dto.GamePhasePlaying || gs.ReadyCheck != nil {
+		t.Errorf("expected the game to start once everyone was ready")
+	}
+}
+
+func TestReadyCheckExpiry(t *testing.T) {
+	gs, players := newTestGame(t)
+	gs.Phase = dto.GamePhaseLobby
+	host := players[0]
+
+	first := time.Now().Add(time.Minute)
+	second := first.Add(time.Minute)
+	(StartReadyCheckData{Seconds: 60, ExpiresAt: &first}).Apply(gs, host)
+	(SetReadyData{Ready: true}).Apply(gs, players[1])
+	(StartReadyCheckData{Seconds: 120, ExpiresAt: &second}).Apply(gs, host)
+
+	if p, _ := gs.Player(players[1].ID); p.Ready {
+		t.Errorf("expected a new ready check to reset readiness")
+	}
+	if err := (CancelReadyCheckData{ExpiresAt: &first}).Apply(gs, host); !errors.Is(err, ErrNoChange) {
+		t.Fatalf("expected an outdated expiry to leave the check running; got %v", err)
+	}
+	if err := (CancelReadyCheckData{ExpiresAt: &second}).Apply(gs, host); err != nil || gs.ReadyCheck != nil {
+		t.Errorf("expected the check to end; got %v", err)
+	}
+}
+
+func TestOverdueReadyCheck(t *testing.T) {
+	gs, players := newTestGame(t)
+	gs.Phase = dto.GamePhaseLobby
+
+	if _, ok := overdueReadyCheck(gs, time.Now()); ok {
+		t.Errorf("expected no overdue check without a check running")
+	}
+
+	expiresAt := time.Now().Add(time.Minute)
+	(StartReadyCheckData{Seconds: 60, ExpiresAt: &expiresAt}).Apply(gs, players[0])
+	if _, ok := overdueReadyCheck(gs, time.Now()); ok {
+		t.Errorf("expected a running check not to be overdue")
+	}
+	if got, ok := overdueReadyCheck(gs, expiresAt.Add(time.Second)); !ok || !got.Equal(expiresAt) {
+		t.Errorf("expected the check to be overdue at %v; got %v, %v", expiresAt, got, ok)
+	}
+}
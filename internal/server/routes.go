@@ -13,7 +13,25 @@ func (s *Server) RegisterRoutes() http.Handler {
 	mux.HandleFunc("POST /user", s.createUser)
 	mux.Handle("GET /user/me", s.requireAuthenticatedUser(http.HandlerFunc(s.getUserData)))
 	mux.HandleFunc("POST /token", s.createAuthenticationToken)
+	mux.HandleFunc("GET /games", s.listGamesHandler)
 	mux.Handle("POST /game/new", s.requireAuthenticatedUser(http.HandlerFunc(s.createNewGame)))
+	mux.Handle("POST /game/{id}/spectator-token", s.requireAuthenticatedUser(http.HandlerFunc(s.createSpectatorToken)))
+	mux.Handle("POST /game/{id}/invite", s.requireAuthenticatedUser(http.HandlerFunc(s.createInviteToken)))
+	mux.HandleFunc("GET /game/{id}/events", s.spectatorEventsHandler)
+	mux.HandleFunc("GET /game/{id}/replay", s.gameReplayHandler)
+	mux.HandleFunc("GET /game/{id}/transcript", s.gameTranscriptHandler)
+	mux.HandleFunc("GET /game/{prefix}/{code}", s.resolveJoinCodeHandler)
+	mux.Handle("GET /game/{id}/print", s.requireAuthenticatedUser(http.HandlerFunc(s.printGameHandler)))
+
+	mux.Handle("GET /wordpacks", s.requireAuthenticatedUser(http.HandlerFunc(s.listWordpacks)))
+	mux.Handle("GET /wordpacks/search", s.requireAuthenticatedUser(http.HandlerFunc(s.searchWordpacks)))
+	mux.Handle("POST /wordpacks", s.requireAuthenticatedUser(http.HandlerFunc(s.createWordpack)))
+	mux.Handle("POST /wordpacks/validate", s.requireAuthenticatedUser(http.HandlerFunc(s.validateWordpack)))
+	mux.Handle("POST /wordpacks/import", s.requireAuthenticatedUser(http.HandlerFunc(s.importWordpack)))
+	mux.Handle("GET /wordpacks/{id}", s.requireAuthenticatedUser(http.HandlerFunc(s.getWordpack)))
+	mux.Handle("PUT /wordpacks/{id}", s.requireAuthenticatedUser(http.HandlerFunc(s.updateWordpack)))
+	mux.Handle("DELETE /wordpacks/{id}", s.requireAuthenticatedUser(http.HandlerFunc(s.deleteWordpack)))
+	mux.Handle("GET /wordpacks/{id}/export", s.requireAuthenticatedUser(http.HandlerFunc(s.exportWordpack)))
 
 	mws := s.CreateMWStack(s.corsMW, s.logAccessMW, s.recoverPanicMW, s.authenticate)
 	// Wrap the mux with CORS middleware
@@ -0,0 +1,336 @@
+package server
+
+import (
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/ninox14/gore-codenames/internal/database/dto"
+)
+
+// GameAction is an accepted client request that changes the game state.
+// Apply must be deterministic: the same action applied to the same state by
+// the same actor always yields the same result.
+type GameAction interface {
+	Apply(gs *dto.GameState, actor dto.GameStatePlayer) error
+}
+
+var (
+	// ErrNoChange is returned by actions that leave the state as it was.
+	ErrNoChange = errors.New("nothing to change")
+
+	ErrNotHost          = errors.New("only the host can do that")
+	ErrNotInLobby       = errors.New("the game has already started")
+	ErrNotPlaying       = errors.New("the game is not being played")
+	ErrNotYourTurn      = errors.New("it is not your team's turn")
+	ErrNotCaptain       = errors.New("only the team's spymaster can do that")
+	ErrCaptainCannotAct = errors.New("spymasters cannot guess")
+	ErrNotSeated        = errors.New("player is not in a team")
+	ErrClueAlreadyGiven = errors.New("a clue was already given this turn")
+	ErrNoClue           = errors.New("waiting for the spymaster's clue")
+	ErrInvalidClue      = errors.New("clue must be a single word that is not on the board")
+	ErrInvalidCard      = errors.New("card does not exist")
+	ErrCardGuessed      = errors.New("card was already guessed")
+	ErrTeamsNotReady    = errors.New("every team needs a spymaster and at least one operative")
+	ErrInvalidPath      = errors.New("invalid destination")
+)
+
+// MaxClueNumber is the highest number a spymaster can attach to a clue.
+const MaxClueNumber = 9
+
+func otherTeam(gs *dto.GameState, color dto.TeamColor) dto.TeamColor {
+	for _, c := range gs.Board.TurnOrder {
+		if c != color {
+			return c
+		}
+	}
+	return color
+}
+
+func teamForPath(path RedisPlayersPath) (dto.TeamColor, bool) {
+	switch path {
+	case TeamRedPath:
+		return dto.TeamColorRed, true
+	case TeamBluePath:
+		return dto.TeamColorBlue, true
+	}
+	return "", false
+}
+
+// unseat removes the player from spectators, the waitlist and every team,
+// dropping their captaincy.
+func unseat(gs *dto.GameState, playerID uuid.UUID) {
+	isPlayer := func(p dto.GameStatePlayer) bool { return p.ID == playerID }
+
+	gs.Spectators = slices.DeleteFunc(gs.Spectators, isPlayer)
+	gs.Waitlist = slices.DeleteFunc(gs.Waitlist, isPlayer)
+	for _, team := range gs.Teams {
+		team.Players = slices.DeleteFunc(team.Players, isPlayer)
+		if team.CaptainID != nil && *team.CaptainID == playerID {
+			team.CaptainID = nil
+		}
+	}
+}
+
+func isInGame(gs *dto.GameState, playerID uuid.UUID) bool {
+	_, seated := gs.TeamOf(playerID)
+	return seated || gs.IsSpectator(playerID) || gs.IsWaitlisted(playerID)
+}
+
+// JoinAction adds the player to the spectators unless they already are in
+// the game or were banned from it. Once the spectator seats are taken, new
+// players wait in line for one.
+type JoinAction struct{}
+
+func (JoinAction) Apply(gs *dto.GameState, actor dto.GameStatePlayer) error {
+	if gs.IsBanned(actor.ID) {
+		return ErrBanned
+	}
+	if isInGame(gs, actor.ID) {
+		return ErrNoChange
+	}
+	if spectatorsFull(gs) {
+		gs.Waitlist = append(gs.Waitlist, actor)
+		return nil
+	}
+	gs.Spectators = append(gs.Spectators, actor)
+	return nil
+}
+
+// LeaveAction frees the spectator seat or waitlist place of a player whose
+// connection closed, so capped seats are not held by people who left. Team
+// seats are kept for players coming back.
+type LeaveAction struct{}
+
+func (LeaveAction) Apply(gs *dto.GameState, actor dto.GameStatePlayer) error {
+	if !gs.IsSpectator(actor.ID) && !gs.IsWaitlisted(actor.ID) {
+		return ErrNoChange
+	}
+
+	unseat(gs, actor.ID)
+	promoteWaitlist(gs)
+	return nil
+}
+
+func (d ChangeTeamData) Apply(gs *dto.GameState, actor dto.GameStatePlayer) error {
+	if gs.Phase == dto.GamePhasePlaying && d.Destination != SpectatorsPath {
+		return ErrNotInLobby
+	}
+
+	if d.Destination == SpectatorsPath {
+		if gs.IsSpectator(actor.ID) {
+			return ErrNoChange
+		}
+		if spectatorsFull(gs) {
+			return ErrSpectatorsFull
+		}
+		unseat(gs, actor.ID)
+		gs.Spectators = append(gs.Spectators, actor)
+		promoteWaitlist(gs)
+		return nil
+	}
+
+	color, ok := teamForPath(d.Destination)
+	if !ok || gs.Teams[color] == nil {
+		return ErrInvalidPath
+	}
+	if current, seated := gs.TeamOf(actor.ID); seated && current == color {
+		return ErrNoChange
+	}
+	if teamFull(gs, color) {
+		return ErrTeamFull
+	}
+
+	unseat(gs, actor.ID)
+	gs.Teams[color].Players = append(gs.Teams[color].Players, actor)
+	promoteWaitlist(gs)
+	return nil
+}
+
+type SetCaptainData struct {
+	// PlayerID defaults to the sender. Only the host can appoint others.
+	PlayerID *uuid.UUID `json:"player_id,omitempty"`
+}
+
+func (d SetCaptainData) Apply(gs *dto.GameState, actor dto.GameStatePlayer) error {
+	if gs.Phase != dto.GamePhaseLobby {
+		return ErrNotInLobby
+	}
+
+	target := actor.ID
+	if d.PlayerID != nil && *d.PlayerID != actor.ID {
+		if gs.HostID != actor.ID {
+			return ErrNotHost
+		}
+		target = *d.PlayerID
+	}
+
+	color, ok := gs.TeamOf(target)
+	if !ok {
+		return ErrNotSeated
+	}
+	if c := gs.Teams[color].CaptainID; c != nil && *c == target {
+		return ErrNoChange
+	}
+
+	gs.Teams[color].CaptainID = &target
+	return nil
+}
+
+type StartGameData struct{}
+
+func (StartGameData) Apply(gs *dto.GameState, actor dto.GameStatePlayer) error {
+	if gs.HostID != actor.ID {
+		return ErrNotHost
+	}
+	return startGame(gs)
+}
+
+func startGame(gs *dto.GameState) error {
+	if gs.Phase != dto.GamePhaseLobby {
+		return ErrNotInLobby
+	}
+	for _, team := range gs.Teams {
+		if team.CaptainID == nil || len(team.Players) < 2 {
+			return ErrTeamsNotReady
+		}
+	}
+
+	gs.Phase = dto.GamePhasePlaying
+	gs.Turn = &dto.Turn{Team: gs.Board.TurnOrder[0]}
+	gs.ReadyCheck = nil
+	return nil
+}
+
+type GiveClueData struct {
+	Word   string `json:"word"`
+	Number int    `json:"number"`
+}
+
+func (d GiveClueData) Apply(gs *dto.GameState, actor dto.GameStatePlayer) error {
+	if gs.Phase != dto.GamePhasePlaying || gs.Turn == nil {
+		return ErrNotPlaying
+	}
+	team := gs.Teams[gs.Turn.Team]
+	if team.CaptainID == nil || *team.CaptainID != actor.ID {
+		return ErrNotCaptain
+	}
+	if gs.Turn.ClueGiven {
+		return ErrClueAlreadyGiven
+	}
+
+	word := strings.TrimSpace(d.Word)
+	if word == "" || strings.ContainsAny(word, " \t") || d.Number < 0 || d.Number > MaxClueNumber {
+		return ErrInvalidClue
+	}
+	for _, w := range gs.Board.CurrentBoard {
+		if strings.EqualFold(w, word) {
+			return ErrInvalidClue
+		}
+	}
+
+	team.Clues = append(team.Clues, &dto.Clue{Word: word, Number: d.Number, Guesses: []int{}})
+	gs.Turn.ClueGiven = true
+	// Zero means unlimited guesses, otherwise one bonus guess is allowed.
+	gs.Turn.GuessesLeft = d.Number + 1
+	if d.Number == 0 {
+		gs.Turn.GuessesLeft = len(gs.Board.CurrentBoard)
+	}
+	return nil
+}
+
+type GuessCardData struct {
+	Index int `json:"index"`
+}
+
+func (d GuessCardData) Apply(gs *dto.GameState, actor dto.GameStatePlayer) error {
+	if gs.Phase != dto.GamePhasePlaying || gs.Turn == nil {
+		return ErrNotPlaying
+	}
+	if err := checkOperative(gs, actor.ID); err != nil {
+		return err
+	}
+	if !gs.Turn.ClueGiven {
+		return ErrNoClue
+	}
+	if d.Index < 0 || d.Index >= len(gs.Board.CurrentBoard) {
+		return ErrInvalidCard
+	}
+	if gs.Board.IsGuessed(d.Index) {
+		return ErrCardGuessed
+	}
+
+	team := gs.Teams[gs.Turn.Team]
+	clue := team.Clues[len(team.Clues)-1]
+	clue.Guesses = append(clue.Guesses, d.Index)
+	gs.Board.GuessedIndexs = append(gs.Board.GuessedIndexs, d.Index)
+	gs.Turn.GuessesLeft--
+
+	switch color := gs.Board.ColorOf(d.Index); color {
+	case dto.CardColorAssassin:
+		finish(gs, otherTeam(gs, gs.Turn.Team))
+	case dto.CardColor(gs.Turn.Team):
+		if allRevealed(gs, gs.Turn.Team) {
+			finish(gs, gs.Turn.Team)
+		} else if gs.Turn.GuessesLeft <= 0 {
+			nextTurn(gs)
+		}
+	case dto.CardColorNeutral:
+		nextTurn(gs)
+	default:
+		if allRevealed(gs, dto.TeamColor(color)) {
+			finish(gs, dto.TeamColor(color))
+		} else {
+			nextTurn(gs)
+		}
+	}
+	return nil
+}
+
+type EndTurnData struct{}
+
+func (EndTurnData) Apply(gs *dto.GameState, actor dto.GameStatePlayer) error {
+	if gs.Phase != dto.GamePhasePlaying || gs.Turn == nil {
+		return ErrNotPlaying
+	}
+	if err := checkOperative(gs, actor.ID); err != nil {
+		return err
+	}
+	if !gs.Turn.ClueGiven {
+		return ErrNoClue
+	}
+
+	nextTurn(gs)
+	return nil
+}
+
+func checkOperative(gs *dto.GameState, playerID uuid.UUID) error {
+	color, ok := gs.TeamOf(playerID)
+	if !ok || color != gs.Turn.Team {
+		return ErrNotYourTurn
+	}
+	if c := gs.Teams[color].CaptainID; c != nil && *c == playerID {
+		return ErrCaptainCannotAct
+	}
+	return nil
+}
+
+func nextTurn(gs *dto.GameState) {
+	gs.Turn = &dto.Turn{Team: otherTeam(gs, gs.Turn.Team)}
+}
+
+func finish(gs *dto.GameState, winner dto.TeamColor) {
+	gs.Phase = dto.GamePhaseFinished
+	gs.Turn = nil
+	gs.Winner = &winner
+}
+
+func allRevealed(gs *dto.GameState, color dto.TeamColor) bool {
+	for _, idx := range gs.Board.WordsByTeam[color] {
+		if !gs.Board.IsGuessed(idx) {
+			return false
+		}
+	}
+	return true
+}
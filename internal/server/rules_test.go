@@ -0,0 +1,127 @@
+package server
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/ninox14/gore-codenames/internal/database/dto"
+)
+
+func newTestGame(t *testing.T) (*dto.GameState, []dto.GameStatePlayer) {
+	t.Helper()
+
+	players := make([]dto.GameStatePlayer, 4)
+	for i := range players {
+		players[i] = dto.GameStatePlayer{ID: uuid.New(), Name: "player"}
+	}
+
+	gs := &dto.GameState{
+		HostID:     players[0].ID,
+		Phase:      dto.GamePhaseLobby,
+		Spectators: []dto.GameStatePlayer{},
+		Teams:      map[dto.TeamColor]*dto.Team{dto.TeamColorRed: CreateEmptyTeam(), dto.TeamColorBlue: CreateEmptyTeam()},
+		Board: &dto.Board{
+			CurrentBoard:   []string{"apple", "bank", "cat", "dog", "egg"},
+			GuessedIndexs:  []int{},
+			AssassinIndexs: []int{4},
+			TurnOrder:      []dto.TeamColor{dto.TeamColorRed, dto.TeamColorBlue},
+			WordsByTeam:    map[dto.TeamColor][]int{dto.TeamColorRed: {0, 1}, dto.TeamColorBlue: {2}},
+		},
+	}
+
+	paths := []RedisPlayersPath{TeamRedPath, TeamRedPath, TeamBluePath, TeamBluePath}
+	for i, p := range players {
+		if err := (JoinAction{}).Apply(gs, p); err != nil {
+			t.Fatalf("join: %v", err)
+		}
+		if err := (ChangeTeamData{Destination: paths[i]}).Apply(gs, p); err != nil {
+			t.Fatalf("change team: %v", err)
+		}
+		if i%2 == 0 {
+			if err := (SetCaptainData{}).Apply(gs, p); err != nil {
+				t.Fatalf("set captain: %v", err)
+			}
+		}
+	}
+	if err := (StartGameData{}).Apply(gs, players[0]); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+
+	return gs, players
+}
+
+func TestGuessingOwnCardsWins(t *testing.T) {
+	gs, players := newTestGame(t)
+	redCaptain, redOperative := players[0], players[1]
+
+	if err := (GuessCardData{Index: 0}).Apply(gs, redOperative); !errors.Is(err, ErrNoClue) {
+		t.Fatalf("expected ErrNoClue before a clue; got %v", err)
+	}
+	if err := (GiveClueData{Word: "Apple", Number: 2}).Apply(gs, redCaptain); !errors.Is(err, ErrInvalidClue) {
+		t.Fatalf("expected a board word to be rejected as clue; got %v", err)
+	}
+	if err := (GiveClueData{Word: "money", Number: 2}).Apply(gs, redCaptain); err != nil {
+		t.Fatalf("give clue: %v", err)
+	}
+	if err := (GuessCardData{Index: 0}).Apply(gs, redCaptain); !errors.Is(err, ErrCaptainCannotAct) {
+		t.Fatalf("expected spymaster guess to be rejected; got %v", err)
+	}
+
+	for _, idx := range []int{0, 1} {
+		if err := (GuessCardData{Index: idx}).Apply(gs, redOperative); err != nil {
+			t.Fatalf("guess %d: %v", idx, err)
+		}
+	}
+
+	if gs.Phase != dto.GamePhaseFinished || gs.Winner == nil || *gs.Winner != dto.TeamColorRed {
+		t.Errorf("expected red to win; got phase %s winner %v", gs.Phase, gs.Winner)
+	}
+}
+
+func TestGuessingAssassinLoses(t *testing.T) {
+	gs, players := newTestGame(t)
+
+	if err := (GiveClueData{Word: "money", Number: 1}).Apply(gs, players[0]); err != nil {
+		t.Fatalf("give clue: %v", err)
+	}
+	if err := (GuessCardData{Index: 4}).Apply(gs, players[1]); err != nil {
+		t.Fatalf("guess: %v", err)
+	}
+
+	if gs.Winner == nil || *gs.Winner != dto.TeamColorBlue {
+		t.Errorf("expected blue to win after the assassin; got %v", gs.Winner)
+	}
+}
+
+func TestHostModeration(t *testing.T) {
+	gs, players := newTestGame(t)
+	host, blueCaptain := players[0], players[2]
+
+	if err := (KickPlayerData{PlayerID: host.ID}).Apply(gs, blueCaptain); !errors.Is(err, ErrNotHost) {
+		t.Fatalf("expected kick by non-host to be rejected; got %v", err)
+	}
+	if err := (BanPlayerData{PlayerID: host.ID}).Apply(gs, host); !errors.Is(err, ErrCannotTargetSelf) {
+		t.Fatalf("expected host to be unable to ban themselves; got %v", err)
+	}
+
+	if err := (BanPlayerData{PlayerID: blueCaptain.ID}).Apply(gs, host); err != nil {
+		t.Fatalf("ban: %v", err)
+	}
+	if isInGame(gs, blueCaptain.ID) || gs.Teams[dto.TeamColorBlue].CaptainID != nil {
+		t.Errorf("expected banned player to be removed from the game")
+	}
+	if err := (JoinAction{}).Apply(gs, blueCaptain); !errors.Is(err, ErrBanned) {
+		t.Errorf("expected banned player to be unable to rejoin; got %v", err)
+	}
+
+	if err := (TransferHostData{PlayerID: players[1].ID}).Apply(gs, host); err != nil {
+		t.Fatalf("transfer host: %v", err)
+	}
+	if gs.HostID != players[1].ID {
+		t.Errorf("expected host to be transferred")
+	}
+	if err := (KickPlayerData{PlayerID: players[3].ID}).Apply(gs, host); !errors.Is(err, ErrNotHost) {
+		t.Errorf("expected former host to lose host rights; got %v", err)
+	}
+}
@@ -2,6 +2,7 @@ package server
 
 import (
 	"context"
+	"expvar"
 	"fmt"
 	"log/slog"
 	"net/http"
@@ -19,7 +20,10 @@ import (
 type config struct {
 	baseURL  string
 	httpPort int
-	cookie   struct {
+	// debugAddr serves /debug/vars apart from the public API. Empty turns
+	// it off.
+	debugAddr string
+	cookie    struct {
 		secretKey string
 	}
 	jwt struct {
@@ -59,11 +63,17 @@ func NewServer() *http.Server {
 	}
 	cfg.baseURL = env.GetString("BASE_URL", "http://localhost:8080")
 	cfg.httpPort = env.GetInt("PORT", 8080)
+	cfg.debugAddr = env.GetString("DEBUG_ADDR", "localhost:6060")
 	cfg.cookie.secretKey = env.GetString("COOKIE_SECRET_KEY", "d4q4sl5zd3exvpfnn5eu776ghd4up2z6")
 	cfg.jwt.secretKey = env.GetString("JWT_SECRET_KEY", "5il7lpknmngmaklaquxzzfz7x5on3pxf")
 
 	logger := slog.New(tint.NewHandler(os.Stdout, &tint.Options{Level: slog.LevelDebug}))
-	gh := NewGameHub(logger, db, rdb)
+	gh := NewGameHub(logger, db, rdb, GameHubConfigFromEnv())
+	go gh.RunJanitor(ctx)
+	go gh.RunPersister(ctx)
+	if cfg.debugAddr != "" {
+		go serveDebug(logger, cfg.debugAddr)
+	}
 	NewServer := &Server{
 		port:   cfg.httpPort,
 		logger: logger,
@@ -85,3 +95,15 @@ func NewServer() *http.Server {
 
 	return server
 }
+
+// serveDebug serves the expvar metrics on an internal address, as they are
+// not meant for players.
+func serveDebug(logger *slog.Logger, addr string) {
+	mux := http.NewServeMux()
+	mux.Handle("GET /debug/vars", expvar.Handler())
+
+	server := &http.Server{Addr: addr, Handler: mux, ReadTimeout: 10 * time.Second, WriteTimeout: 10 * time.Second}
+	if err := server.ListenAndServe(); err != nil {
+		logger.Error("Debug server stopped", "addr", addr, "err", err)
+	}
+}
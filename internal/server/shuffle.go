@@ -0,0 +1,280 @@
+package server
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/ninox14/gore-codenames/internal/database/dto"
+	"github.com/ninox14/gore-codenames/internal/database/sqlc"
+	"github.com/redis/go-redis/v9"
+)
+
+// DefaultRating is assumed for players without a stored rating.
+const DefaultRating = 1000
+
+type ShuffleMode string
+
+const (
+	// ShuffleBySize splits players randomly into teams of equal size.
+	ShuffleBySize ShuffleMode = "size"
+	// ShuffleByRating also evens out the summed ratings of the teams.
+	ShuffleByRating ShuffleMode = "rating"
+)
+
+func (ShuffleMode) JSONSchemaEnum() []any {
+	return []any{ShuffleBySize, ShuffleByRating}
+}
+
+var (
+	ErrInvalidShuffleMode = errors.New("shuffle mode must be size or rating")
+	ErrShuffleOutdated    = errors.New("players changed seats while teams were shuffled")
+)
+
+// ShuffleTeamsData reassigns every seated player to a team. Clients only send
+// the mode, the server draws Teams, which is kept so replays deal the same
+// teams again.
+type ShuffleTeamsData struct {
+	Mode  ShuffleMode                   `json:"mode"`
+	Teams map[dto.TeamColor][]uuid.UUID `json:"teams,omitempty"`
+}
+
+func (d ShuffleTeamsData) Apply(gs *dto.GameState, actor dto.GameStatePlayer) error {
+	if gs.HostID != actor.ID {
+		return ErrNotHost
+	}
+	if gs.Phase != dto.GamePhaseLobby {
+		return ErrNotInLobby
+	}
+	if d.Mode != ShuffleBySize && d.Mode != ShuffleByRating {
+		return ErrInvalidShuffleMode
+	}
+
+	seated := make(map[uuid.UUID]dto.GameStatePlayer)
+	for _, team := range gs.Teams {
+		for _, p := range team.Players {
+			seated[p.ID] = p
+		}
+	}
+
+	// Every seated player has to be dealt exactly once.
+	players := make(map[dto.TeamColor][]dto.GameStatePlayer, len(gs.Teams))
+	for color, ids := range d.Teams {
+		if gs.Teams[color] == nil {
+			return ErrInvalidPath
+		}
+		for _, id := range ids {
+			p, ok := seated[id]
+			if !ok {
+				return ErrShuffleOutdated
+			}
+			players[color] = append(players[color], p)
+			delete(seated, id)
+		}
+	}
+	if len(seated) != 0 {
+		return ErrShuffleOutdated
+	}
+
+	for color, team := range gs.Teams {
+		team.Players = players[color]
+		if team.Players == nil {
+			team.Players = []dto.GameStatePlayer{}
+		}
+		team.CaptainID = nil
+	}
+	return nil
+}
+
+// drawTeams deals the seated players into teams. Both modes keep team sizes
+// within one of each other; by rating, the strongest players are dealt first,
+// each to the team with the lowest total so far.
+func drawTeams(gs *dto.GameState, mode ShuffleMode, ratings map[uuid.UUID]int, rng *rand.Rand) map[dto.TeamColor][]uuid.UUID {
+	colors := sortedTeams(gs)
+
+	var ids []uuid.UUID
+	for _, color := range colors {
+		for _, p := range gs.Teams[color].Players {
+			ids = append(ids, p.ID)
+		}
+	}
+	rng.Shuffle(len(ids), func(i, j int) { ids[i], ids[j] = ids[j], ids[i] })
+
+	teams := make(map[dto.TeamColor][]uuid.UUID, len(colors))
+	if mode == ShuffleBySize {
+		offset := rng.IntN(len(colors))
+		for i, id := range ids {
+			color := colors[(i+offset)%len(colors)]
+			teams[color] = append(teams[color], id)
+		}
+		return teams
+	}
+
+	rating := func(id uuid.UUID) int {
+		if r, ok := ratings[id]; ok {
+			return r
+		}
+		return DefaultRating
+	}
+	// Stable, so equally rated players keep their random order.
+	slices.SortStableFunc(ids, func(a, b uuid.UUID) int { return cmp.Compare(rating(b), rating(a)) })
+
+	capacity := (len(ids) + len(colors) - 1) / len(colors)
+	totals := make(map[dto.TeamColor]int, len(colors))
+	for _, id := range ids {
+		var best dto.TeamColor
+		for _, color := range colors {
+			if len(teams[color]) >= capacity {
+				continue
+			}
+			if best == "" || totals[color] < totals[best] {
+				best = color
+			}
+		}
+		teams[best] = append(teams[best], id)
+		totals[best] += rating(id)
+	}
+	return teams
+}
+
+// shuffleTeamsScript writes the players of every team and drops their
+// captains in one step, provided the state is still at the expected version.
+var shuffleTeamsScript = redis.NewScript(`
+	local key      = KEYS[1]
+	local expected = tonumber(ARGV[1])
+
+	local raw = redis.call("JSON.GET", key, "$.version")
+	if not raw then
+		return redis.error_reply("missing state")
+	end
+	if cjson.decode(raw)[1] ~= expected then
+		return redis.error_reply("version conflict")
+	end
+
+	for i = 2, #ARGV, 2 do
+		redis.call("JSON.SET", key, "$.teams." .. ARGV[i] .. ".players", ARGV[i + 1])
+		redis.call("JSON.SET", key, "$.teams." .. ARGV[i] .. ".captain_id", "null")
+	end
+
+	return cjson.decode(redis.call("JSON.NUMINCRBY", key, "$.version", 1))[1]`)
+
+// writeTeams stores the teams of gs, which was read at version, and returns
+// the new version.
+func (h *GameHub) writeTeams(ctx context.Context, gameID uuid.UUID, version int64, gs *dto.GameState) (int64, error) {
+	args := []any{version}
+	for _, color := range sortedTeams(gs) {
+		players, err := json.Marshal(gs.Teams[color].Players)
+		if err != nil {
+			return 0, err
+		}
+		args = append(args, string(color), players)
+	}
+
+	newVersion, err := shuffleTeamsScript.Run(ctx, h.rdb, []string{GetRedisGameKey(gameID)}, args...).Int64()
+	switch {
+	case err != nil && strings.Contains(err.Error(), "version conflict"):
+		return 0, ErrStateConflict
+	case err != nil && strings.Contains(err.Error(), "missing state"):
+		return 0, missingState(gameID)
+	case err != nil:
+		return 0, err
+	}
+
+	if err := h.TouchGame(ctx, gameID); err != nil {
+		h.logger.Error("Could not refresh game presence", "gameId", gameID, "err", err)
+	}
+	return newVersion, nil
+}
+
+func (h *GameHub) playerRatings(ctx context.Context, gs *dto.GameState) (map[uuid.UUID]int, error) {
+	return listRatings(ctx, h.db.Queries, seatedPlayers(gs))
+}
+
+// seatedPlayers returns the ids of every player in a team.
+func seatedPlayers(gs *dto.GameState) []uuid.UUID {
+	var ids []uuid.UUID
+	for _, team := range gs.Teams {
+		for _, p := range team.Players {
+			ids = append(ids, p.ID)
+		}
+	}
+	return ids
+}
+
+// listRatings returns the stored ratings of the players. Players who never
+// finished a game are left out.
+func listRatings(ctx context.Context, q *sqlc.Queries, ids []uuid.UUID) (map[uuid.UUID]int, error) {
+	rows, err := q.ListPlayerRatings(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	ratings := make(map[uuid.UUID]int, len(rows))
+	for _, row := range rows {
+		ratings[row.UserID] = int(row.Rating)
+	}
+	return ratings, nil
+}
+
+// ShuffleTeams draws new teams and writes them with shuffleTeamsScript rather
+// than through UpdateGameState, so only the team arrays are touched.
+func (g *Game) ShuffleTeams(ctx context.Context, playerID uuid.UUID, data ShuffleTeamsData, expectedVersion *int64) {
+	player := g.GetGameHubPlayer(playerID)
+	if player == nil {
+		g.hub.logger.Error("Action from player not in game", "player", playerID, "gameId", g.ID)
+		return
+	}
+	actor := GameHubPlayerToGameStatePlayer(player)
+
+	action, version, err := g.shuffleTeams(ctx, actor, data.Mode, expectedVersion)
+	if err != nil {
+		g.hub.logger.Debug("Rejected game action", "player", playerID, "action", fmt.Sprintf("%T", data), "err", err)
+		player.send(ctx, Message{Type: MsgError, GameID: &g.ID, Data: ErrorData{Message: "Could not shuffle teams", Err: err.Error()}})
+		return
+	}
+
+	g.recordEvent(ctx, version, actor, action)
+	g.hub.persister.markDirty(g.ID)
+	g.broadcastGameState(ctx)
+}
+
+func (g *Game) shuffleTeams(ctx context.Context, actor dto.GameStatePlayer, mode ShuffleMode, expectedVersion *int64) (ShuffleTeamsData, int64, error) {
+	for range maxStateRetries {
+		gs, err := g.hub.GetGameState(ctx, g.ID)
+		if err != nil {
+			return ShuffleTeamsData{}, 0, err
+		}
+		if expectedVersion != nil && gs.Version != *expectedVersion {
+			return ShuffleTeamsData{}, 0, ErrStateConflict
+		}
+
+		var ratings map[uuid.UUID]int
+		if mode == ShuffleByRating {
+			if ratings, err = g.hub.playerRatings(ctx, &gs); err != nil {
+				return ShuffleTeamsData{}, 0, err
+			}
+		}
+
+		action := ShuffleTeamsData{Mode: mode}
+		if mode == ShuffleBySize || mode == ShuffleByRating {
+			action.Teams = drawTeams(&gs, mode, ratings, rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64())))
+		}
+
+		version := gs.Version
+		if err := action.Apply(&gs, actor); err != nil {
+			return ShuffleTeamsData{}, 0, err
+		}
+
+		newVersion, err := g.hub.writeTeams(ctx, g.ID, version, &gs)
+		if errors.Is(err, ErrStateConflict) && expectedVersion == nil {
+			continue
+		}
+		return action, newVersion, err
+	}
+	return ShuffleTeamsData{}, 0, ErrStateConflict
+}
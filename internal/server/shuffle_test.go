@@ -0,0 +1,52 @@
+package server
+
+import (
+	"errors"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/ninox14/gore-codenames/internal/database/dto"
+)
+
+func TestShuffleByRatingSplitsSkill(t *testing.T) {
+	gs, players := newTestGame(t)
+	gs.Phase = dto.GamePhaseLobby
+	host := players[0]
+
+	ratings := map[uuid.UUID]int{
+		players[0].ID: 1800,
+		players[1].ID: 1700,
+		players[2].ID: 900,
+		players[3].ID: 800,
+	}
+	rng := rand.New(rand.NewPCG(1, 2))
+	action := ShuffleTeamsData{Mode: ShuffleByRating, Teams: drawTeams(gs, ShuffleByRating, ratings, rng)}
+
+	if err := action.Apply(gs, host); err != nil {
+		t.Fatalf("shuffle: %v", err)
+	}
+	for color, team := range gs.Teams {
+		total := 0
+		for _, p := range team.Players {
+			total += ratings[p.ID]
+		}
+		if len(team.Players) != 2 || total != 2600 {
+			t.Errorf("expected %s to have two players rated 2600 in total; got %d rated %d", color, len(team.Players), total)
+		}
+		if team.CaptainID != nil {
+			t.Errorf("expected %s to need a new spymaster", color)
+		}
+	}
+
+	if err := action.Apply(gs, players[1]); !errors.Is(err, ErrNotHost) {
+		t.Errorf("expected shuffle by non-host to be rejected; got %v", err)
+	}
+
+	late := dto.GameStatePlayer{ID: uuid.New(), Name: "late"}
+	(JoinAction{}).Apply(gs, late)
+	(ChangeTeamData{Destination: TeamBluePath}).Apply(gs, late)
+	if err := action.Apply(gs, host); !errors.Is(err, ErrShuffleOutdated) {
+		t.Errorf("expected a shuffle missing a player to be rejected; got %v", err)
+	}
+}
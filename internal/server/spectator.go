@@ -0,0 +1,124 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pascaldekloe/jwt"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// SpectatorStreamSize bounds the events kept for Last-Event-ID resume.
+	SpectatorStreamSize  = 256
+	SpectatorTokenExpiry = 24 * time.Hour
+	spectatorKeepAlive   = 15 * time.Second
+)
+
+var ErrInvalidSpectatorToken = errors.New("invalid spectator token")
+
+func GetRedisEventsKey(gameID uuid.UUID) string {
+	return fmt.Sprintf("game:%s:events", gameID)
+}
+
+// publishSpectatorEvent appends an already redacted event to the game's
+// spectator stream. Failures are logged only, as players are not affected.
+func (g *Game) publishSpectatorEvent(ctx context.Context, t MessageType, data any) {
+	js, err := json.Marshal(data)
+	if err != nil {
+		g.hub.logger.Error("Could not encode spectator event", "gameId", g.ID, "err", err)
+		return
+	}
+
+	err = g.hub.rdb.XAdd(ctx, &redis.XAddArgs{
+		Stream: GetRedisEventsKey(g.ID),
+		MaxLen: SpectatorStreamSize,
+		Approx: true,
+		Values: map[string]any{"type": string(t), "data": js},
+	}).Err()
+	if err != nil {
+		g.hub.logger.Error("Could not publish spectator event", "gameId", g.ID, "err", err)
+	}
+}
+
+// spectatorAudience keeps spectator tokens from being accepted as
+// authentication tokens and vice versa.
+func (s *Server) spectatorAudience() string {
+	return s.config.baseURL + "/spectate"
+}
+
+func (s *Server) signSpectatorToken(gameID uuid.UUID) ([]byte, time.Time, error) {
+	var claims jwt.Claims
+	claims.Subject = gameID.String()
+
+	// Truncated rather than rounded, so the token is usable right away.
+	now := time.Now().Truncate(time.Second)
+	expiry := time.Now().Add(SpectatorTokenExpiry)
+	claims.Issued = jwt.NewNumericTime(now)
+	claims.NotBefore = jwt.NewNumericTime(now)
+	claims.Expires = jwt.NewNumericTime(expiry.Round(time.Second))
+
+	claims.Issuer = s.config.baseURL
+	claims.Audiences = []string{s.spectatorAudience()}
+
+	token, err := claims.HMACSign(jwt.HS256, []byte(s.config.jwt.secretKey))
+	return token, expiry, err
+}
+
+func (s *Server) checkSpectatorToken(token string, gameID uuid.UUID) error {
+	claims, err := jwt.HMACCheck([]byte(token), []byte(s.config.jwt.secretKey))
+	if err != nil {
+		return ErrInvalidSpectatorToken
+	}
+
+	if !claims.Valid(time.Now()) ||
+		claims.Issuer != s.config.baseURL ||
+		!claims.AcceptAudience(s.spectatorAudience()) ||
+		claims.Subject != gameID.String() {
+		return ErrInvalidSpectatorToken
+	}
+
+	return nil
+}
+
+// writeSSE writes one Server-Sent Event. Data is expected to be single-line
+// JSON.
+func writeSSE(w io.Writer, id, event string, data []byte) error {
+	_, err := fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", id, event, data)
+	return err
+}
+
+// lastSpectatorEventID returns the ID of the newest event in the stream, or
+// "0" when it is empty, so a snapshot can be resumed from.
+func (h *GameHub) lastSpectatorEventID(ctx context.Context, gameID uuid.UUID) (string, error) {
+	entries, err := h.rdb.XRevRangeN(ctx, GetRedisEventsKey(gameID), "+", "-", 1).Result()
+	if err != nil {
+		return "", err
+	}
+	if len(entries) == 0 {
+		return "0", nil
+	}
+	return entries[0].ID, nil
+}
+
+// readSpectatorEvents blocks until events newer than lastID arrive or the
+// keep-alive interval passes, in which case it returns no events.
+func (h *GameHub) readSpectatorEvents(ctx context.Context, gameID uuid.UUID, lastID string) ([]redis.XMessage, error) {
+	streams, err := h.rdb.XRead(ctx, &redis.XReadArgs{
+		Streams: []string{GetRedisEventsKey(gameID), lastID},
+		Count:   50,
+		Block:   spectatorKeepAlive,
+	}).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil || len(streams) == 0 {
+		return nil, err
+	}
+	return streams[0].Messages, nil
+}
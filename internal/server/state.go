@@ -0,0 +1,182 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/ninox14/gore-codenames/internal/database/dto"
+	"github.com/ninox14/gore-codenames/internal/database/sqlc"
+	"github.com/redis/go-redis/v9"
+)
+
+// maxStateRetries bounds how often a mutation is retried when another writer
+// changed the game state between read and write.
+const maxStateRetries = 5
+
+var (
+	ErrStateConflict = errors.New("game state was changed by someone else, please retry")
+	// ErrGameStateMissing means Redis holds no state for the game, either
+	// because it expired or was flushed. See GameHub.ensureGameState.
+	ErrGameStateMissing = errors.New("game state is missing")
+	ErrGameOver         = errors.New("game is over and cannot be resumed")
+)
+
+func missingState(gameID uuid.UUID) error {
+	return fmt.Errorf("%w for game %s", ErrGameStateMissing, gameID)
+}
+
+func decodeGameState(raw string) (dto.GameState, error) {
+	var gs []dto.GameState
+	if err := json.Unmarshal([]byte(raw), &gs); err != nil {
+		return dto.GameState{}, fmt.Errorf("could not unmarshal game state: %w", err)
+	}
+	if len(gs) < 1 {
+		return dto.GameState{}, errors.New("empty game state")
+	}
+
+	// Documents written before phases existed are still in the lobby.
+	if gs[0].Phase == "" {
+		gs[0].Phase = dto.GamePhaseLobby
+	}
+	return gs[0], nil
+}
+
+// UpdateGameState runs fn on the current state and writes the result back as
+// a compare-and-set on its version, inside a WATCH/MULTI transaction.
+//
+// When expectedVersion is set the caller acted on that version and gets
+// ErrStateConflict if the state moved on. Otherwise the update is retried on
+// conflicts. If fn returns ErrNoChange nothing is written and the current
+// state is returned with the error.
+func (h *GameHub) UpdateGameState(ctx context.Context, gameID uuid.UUID, expectedVersion *int64, fn func(gs *dto.GameState) error) (dto.GameState, error) {
+	key := GetRedisGameKey(gameID)
+
+	for range maxStateRetries {
+		var result dto.GameState
+
+		err := h.rdb.Watch(ctx, func(tx *redis.Tx) error {
+			raw, err := tx.JSONGet(ctx, key, "$").Result()
+			if errors.Is(err, redis.Nil) || (err == nil && raw == "") {
+				return missingState(gameID)
+			}
+			if err != nil {
+				return err
+			}
+			gs, err := decodeGameState(raw)
+			if err != nil {
+				return err
+			}
+
+			if expectedVersion != nil && gs.Version != *expectedVersion {
+				return ErrStateConflict
+			}
+
+			version := gs.Version
+			if err := fn(&gs); err != nil {
+				result = gs
+				return err
+			}
+			gs.Version = version + 1
+
+			js, err := json.Marshal(gs)
+			if err != nil {
+				return err
+			}
+
+			_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+				pipe.JSONSet(ctx, key, "$", js)
+				h.touchGame(ctx, pipe, gameID)
+				return nil
+			})
+			result = gs
+			return err
+		}, key)
+
+		if errors.Is(err, redis.TxFailedErr) {
+			if expectedVersion != nil {
+				return dto.GameState{}, ErrStateConflict
+			}
+			continue
+		}
+		return result, err
+	}
+
+	return dto.GameState{}, ErrStateConflict
+}
+
+// ensureGameState rebuilds a game's Redis document from its last Postgres
+// snapshot when the key is missing, e.g. after Redis was restarted without
+// persistence. Only games that have not finished are resumed.
+func (h *GameHub) ensureGameState(ctx context.Context, gameID uuid.UUID) error {
+	key := GetRedisGameKey(gameID)
+
+	exists, err := h.rdb.Exists(ctx, key).Result()
+	if err != nil || exists > 0 {
+		return err
+	}
+
+	game, err := h.db.Queries.GetGameByID(ctx, gameID)
+	if err != nil {
+		return fmt.Errorf("could not load game %s: %w", gameID, err)
+	}
+	if game.Status != sqlc.GameStatusInitial && game.Status != sqlc.GameStatusStarted {
+		return ErrGameOver
+	}
+	gs, err := h.latestGameState(ctx, game)
+	if err != nil {
+		return err
+	}
+
+	js, err := json.Marshal(gs)
+	if err != nil {
+		return err
+	}
+
+	// NX keeps a document another node rehydrated first.
+	pipe := h.rdb.TxPipeline()
+	pipe.JSONSetMode(ctx, key, "$", js, "NX")
+	h.touchGame(ctx, pipe, gameID)
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return err
+	}
+
+	h.logger.Info("Rehydrated game state from Postgres", "gameId", gameID, "version", gs.Version)
+	return nil
+}
+
+// latestGameState rebuilds the state from the event log, which is written on
+// every action, rather than the snapshot, which can lag behind by up to
+// SnapshotInterval. Event seqs are state versions, so resuming from an older
+// version would reuse seqs already in the log.
+func (h *GameHub) latestGameState(ctx context.Context, game sqlc.Game) (*dto.GameState, error) {
+	events, err := h.db.Queries.ListGameEvents(ctx, game.ID)
+	if err != nil {
+		return nil, fmt.Errorf("could not load events of game %s: %w", game.ID, err)
+	}
+	snapshot := game.GameState
+
+	if len(events) > 0 {
+		folded, err := ReplayGameEvents(events, -1)
+		if err == nil && (snapshot == nil || folded.Version >= snapshot.Version) {
+			return &folded, nil
+		}
+		if err != nil {
+			h.logger.Warn("Could not replay game events, resuming from snapshot", "gameId", game.ID, "err", err)
+		}
+
+		// Never go back behind the log, whatever state is resumed.
+		if last := events[len(events)-1].Seq; snapshot != nil && snapshot.Version < last {
+			gs := *snapshot
+			gs.Version = last
+			return &gs, nil
+		}
+	}
+
+	if snapshot == nil {
+		return nil, missingState(game.ID)
+	}
+	return snapshot, nil
+}
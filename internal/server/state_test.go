@@ -0,0 +1,300 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ninox14/gore-codenames/internal/database/dto"
+	"github.com/redis/go-redis/v9"
+)
+
+// fakeRedis speaks just enough RESP for UpdateGameState: JSON documents at
+// the root path, WATCH and MULTI/EXEC, and the expiry and presence writes
+// that ride along. Expiries are accepted and ignored; sorted sets keep their
+// scores as sent.
+type fakeRedis struct {
+	mu       sync.Mutex
+	docs     map[string]string
+	zsets    map[string]map[string]string
+	modified map[string]int
+}
+
+func newFakeRedis(t *testing.T) *redis.Client {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	f := &fakeRedis{docs: make(map[string]string), zsets: make(map[string]map[string]string), modified: make(map[string]int)}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go f.serve(conn)
+		}
+	}()
+
+	rdb := redis.NewClient(&redis.Options{Addr: ln.Addr().String(), Protocol: 2, DisableIdentity: true})
+	t.Cleanup(func() { rdb.Close() })
+	return rdb
+}
+
+func (f *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+
+	watched := make(map[string]int)
+	var queued [][]string
+	inMulti := false
+
+	for {
+		cmd, err := readCommand(r)
+		if err != nil {
+			return
+		}
+
+		var reply string
+		switch name := strings.ToUpper(cmd[0]); {
+		case name == "WATCH":
+			f.mu.Lock()
+			for _, key := range cmd[1:] {
+				watched[key] = f.modified[key]
+			}
+			f.mu.Unlock()
+			reply = "+OK\r\n"
+		case name == "UNWATCH":
+			clear(watched)
+			reply = "+OK\r\n"
+		case name == "MULTI":
+			inMulti = true
+			reply = "+OK\r\n"
+		case name == "DISCARD":
+			inMulti, queued = false, nil
+			clear(watched)
+			reply = "+OK\r\n"
+		case name == "EXEC":
+			f.mu.Lock()
+			aborted := false
+			for key, seen := range watched {
+				aborted = aborted || f.modified[key] != seen
+			}
+			if aborted {
+				reply = "*-1\r\n"
+			} else {
+				reply = fmt.Sprintf("*%d\r\n", len(queued))
+				for _, c := range queued {
+					reply += f.exec(c)
+				}
+			}
+			f.mu.Unlock()
+			inMulti, queued = false, nil
+			clear(watched)
+		case inMulti:
+			queued = append(queued, cmd)
+			reply = "+QUEUED\r\n"
+		default:
+			f.mu.Lock()
+			reply = f.exec(cmd)
+			f.mu.Unlock()
+		}
+
+		if _, err := io.WriteString(conn, reply); err != nil {
+			return
+		}
+	}
+}
+
+// exec runs a command with f.mu held and returns its encoded reply.
+func (f *fakeRedis) exec(cmd []string) string {
+	switch strings.ToUpper(cmd[0]) {
+	case "PING":
+		return "+PONG\r\n"
+	case "JSON.GET":
+		doc, ok := f.docs[cmd[1]]
+		if !ok {
+			return "$-1\r\n"
+		}
+		doc = "[" + doc + "]"
+		return fmt.Sprintf("$%d\r\n%s\r\n", len(doc), doc)
+	case "JSON.SET":
+		if _, ok := f.docs[cmd[1]]; ok && len(cmd) > 4 && strings.EqualFold(cmd[4], "NX") {
+			return "$-1\r\n"
+		}
+		f.docs[cmd[1]] = cmd[3]
+		f.modified[cmd[1]]++
+		return "+OK\r\n"
+	case "EXPIRE":
+		return ":1\r\n"
+	case "ZADD":
+		args, nx := cmd[2:], false
+		if strings.EqualFold(args[0], "NX") {
+			args, nx = args[1:], true
+		}
+		set := f.zsets[cmd[1]]
+		if set == nil {
+			set = make(map[string]string)
+			f.zsets[cmd[1]] = set
+		}
+		added := 0
+		for i := 0; i+1 < len(args); i += 2 {
+			if _, ok := set[args[i+1]]; ok && nx {
+				continue
+			} else if !ok {
+				added++
+			}
+			set[args[i+1]] = args[i]
+		}
+		f.modified[cmd[1]]++
+		return fmt.Sprintf(":%d\r\n", added)
+	case "ZREM":
+		removed := 0
+		for _, member := range cmd[2:] {
+			if _, ok := f.zsets[cmd[1]][member]; ok {
+				delete(f.zsets[cmd[1]], member)
+				removed++
+			}
+		}
+		f.modified[cmd[1]]++
+		return fmt.Sprintf(":%d\r\n", removed)
+	case "ZSCORE":
+		score, ok := f.zsets[cmd[1]][cmd[2]]
+		if !ok {
+			return "$-1\r\n"
+		}
+		return fmt.Sprintf("$%d\r\n%s\r\n", len(score), score)
+	default:
+		return fmt.Sprintf("-ERR unknown command '%s'\r\n", cmd[0])
+	}
+}
+
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "*")))
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := make([]string, n)
+	for i := range cmd {
+		if line, err = r.ReadString('\n'); err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "$")))
+		if err != nil {
+			return nil, err
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		cmd[i] = string(buf[:size])
+	}
+	return cmd, nil
+}
+
+func newTestHub(t *testing.T) (*GameHub, uuid.UUID) {
+	t.Helper()
+
+	h := &GameHub{rdb: newFakeRedis(t), config: GameHubConfig{GameTTL: time.Hour}}
+	gs, _ := newTestGame(t)
+	gameID := uuid.New()
+	if err := h.rdb.JSONSet(context.Background(), GetRedisGameKey(gameID), "$", gs).Err(); err != nil {
+		t.Fatalf("seed game state: %v", err)
+	}
+	return h, gameID
+}
+
+// interfere writes the game state as another node would, bumping its version.
+func interfere(t *testing.T, h *GameHub, gameID uuid.UUID) {
+	t.Helper()
+
+	gs, err := h.GetGameState(context.Background(), gameID)
+	if err != nil {
+		t.Fatalf("read game state: %v", err)
+	}
+	gs.Version++
+	if err := h.rdb.JSONSet(context.Background(), GetRedisGameKey(gameID), "$", gs).Err(); err != nil {
+		t.Fatalf("write game state: %v", err)
+	}
+}
+
+func TestUpdateGameStateRetriesOnConflict(t *testing.T) {
+	h, gameID := newTestHub(t)
+	ctx := context.Background()
+
+	calls := 0
+	gs, err := h.UpdateGameState(ctx, gameID, nil, func(gs *dto.GameState) error {
+		calls++
+		if calls == 1 {
+			interfere(t, h, gameID)
+		}
+		gs.Settings.MaxSpectators = 42
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("expected the update to be retried once; ran %d times", calls)
+	}
+	if gs.Version != 2 || gs.Settings.MaxSpectators != 42 {
+		t.Errorf("expected the retry to apply on top of the other write; got version %d", gs.Version)
+	}
+
+	stored, err := h.GetGameState(ctx, gameID)
+	if err != nil || stored.Version != 2 || stored.Settings.MaxSpectators != 42 {
+		t.Errorf("expected the retried update to be stored; got version %d, %v", stored.Version, err)
+	}
+}
+
+func TestUpdateGameStateExpectedVersion(t *testing.T) {
+	h, gameID := newTestHub(t)
+	ctx := context.Background()
+	noop := func(gs *dto.GameState) error { return nil }
+
+	stale := int64(7)
+	if _, err := h.UpdateGameState(ctx, gameID, &stale, noop); !errors.Is(err, ErrStateConflict) {
+		t.Errorf("expected a stale version to conflict; got %v", err)
+	}
+
+	// A write landing while the action runs conflicts instead of retrying, as
+	// the player acted on a state that no longer exists.
+	current := int64(0)
+	calls := 0
+	_, err := h.UpdateGameState(ctx, gameID, &current, func(gs *dto.GameState) error {
+		calls++
+		interfere(t, h, gameID)
+		return nil
+	})
+	if !errors.Is(err, ErrStateConflict) || calls != 1 {
+		t.Errorf("expected one attempt and a conflict; got %d attempts and %v", calls, err)
+	}
+
+	current = 1
+	gs, err := h.UpdateGameState(ctx, gameID, &current, noop)
+	if err != nil || gs.Version != 2 {
+		t.Errorf("expected the current version to apply; got version %d, %v", gs.Version, err)
+	}
+
+	if _, err := h.UpdateGameState(ctx, uuid.New(), nil, noop); !errors.Is(err, ErrGameStateMissing) {
+		t.Errorf("expected a missing game to be reported; got %v", err)
+	}
+}
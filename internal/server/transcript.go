@@ -0,0 +1,290 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ninox14/gore-codenames/internal/database/dto"
+	"github.com/ninox14/gore-codenames/internal/database/sqlc"
+)
+
+var ErrNoGameState = errors.New("game has no stored state")
+
+type OutcomeReason string
+
+const (
+	OutcomeAssassin   OutcomeReason = "assassin"
+	OutcomeAllCards   OutcomeReason = "all_cards"
+	OutcomeUnfinished OutcomeReason = "unfinished"
+)
+
+type TranscriptTeam struct {
+	Color     dto.TeamColor         `json:"color"`
+	Spymaster *dto.GameStatePlayer  `json:"spymaster"`
+	Players   []dto.GameStatePlayer `json:"players"`
+	CardsLeft int                   `json:"cards_left"`
+}
+
+type TranscriptGuess struct {
+	Index   int           `json:"index"`
+	Word    string        `json:"word"`
+	Color   dto.CardColor `json:"color"`
+	Correct bool          `json:"correct"`
+}
+
+type TranscriptTurn struct {
+	Number  int               `json:"number"`
+	Team    dto.TeamColor     `json:"team"`
+	Clue    string            `json:"clue"`
+	Count   int               `json:"count"`
+	Guesses []TranscriptGuess `json:"guesses"`
+	// Mistakes counts guesses that were not the team's own cards.
+	Mistakes int `json:"mistakes"`
+}
+
+type TranscriptCard struct {
+	Index   int           `json:"index"`
+	Word    string        `json:"word"`
+	Color   dto.CardColor `json:"color"`
+	Guessed bool          `json:"guessed"`
+}
+
+// Transcript is a shareable summary of a game built from its stored state.
+type Transcript struct {
+	GameID    uuid.UUID        `json:"game_id"`
+	Status    sqlc.GameStatus  `json:"status"`
+	CreatedAt time.Time        `json:"created_at"`
+	StartedAt *time.Time       `json:"started_at"`
+	Winner    *dto.TeamColor   `json:"winner"`
+	Reason    OutcomeReason    `json:"reason"`
+	Teams     []TranscriptTeam `json:"teams"`
+	Turns     []TranscriptTurn `json:"turns"`
+	Size      dto.BoardSize    `json:"size"`
+	Cards     []TranscriptCard `json:"cards"`
+}
+
+func findPlayer(team *dto.Team, id *uuid.UUID) *dto.GameStatePlayer {
+	if id == nil {
+		return nil
+	}
+	for _, p := range team.Players {
+		if p.ID == *id {
+			return &p
+		}
+	}
+	return nil
+}
+
+// NewTranscript builds the transcript of a game from its games row.
+func NewTranscript(game sqlc.Game) (Transcript, error) {
+	gs := game.GameState
+	if gs == nil || gs.Board == nil {
+		return Transcript{}, ErrNoGameState
+	}
+	board := gs.Board
+
+	t := Transcript{
+		GameID:    game.ID,
+		Status:    game.Status,
+		CreatedAt: game.CreatedAt.Time,
+		Winner:    gs.Winner,
+		Reason:    OutcomeUnfinished,
+		Teams:     make([]TranscriptTeam, 0, len(board.TurnOrder)),
+		Turns:     make([]TranscriptTurn, 0),
+		Cards:     make([]TranscriptCard, 0, len(board.CurrentBoard)),
+	}
+	if game.StartedAt.Valid {
+		t.StartedAt = &game.StartedAt.Time
+	}
+	if board.Size != nil {
+		t.Size = *board.Size
+	}
+
+	for i, word := range board.CurrentBoard {
+		t.Cards = append(t.Cards, TranscriptCard{Index: i, Word: word, Color: board.ColorOf(i), Guessed: board.IsGuessed(i)})
+	}
+
+	for _, color := range board.TurnOrder {
+		team := gs.Teams[color]
+		if team == nil {
+			continue
+		}
+
+		left := 0
+		for _, idx := range board.WordsByTeam[color] {
+			if !board.IsGuessed(idx) {
+				left++
+			}
+		}
+		t.Teams = append(t.Teams, TranscriptTeam{Color: color, Spymaster: findPlayer(team, team.CaptainID), Players: team.Players, CardsLeft: left})
+	}
+
+	// Every turn starts with exactly one clue and teams alternate, so the
+	// clue lists interleave in turn order.
+	for round := 0; ; round++ {
+		added := false
+		for _, color := range board.TurnOrder {
+			team := gs.Teams[color]
+			if team == nil || round >= len(team.Clues) {
+				continue
+			}
+			added = true
+
+			clue := team.Clues[round]
+			turn := TranscriptTurn{Number: len(t.Turns) + 1, Team: color, Clue: clue.Word, Count: clue.Number, Guesses: make([]TranscriptGuess, 0, len(clue.Guesses))}
+			for _, idx := range clue.Guesses {
+				g := TranscriptGuess{Index: idx, Color: board.ColorOf(idx)}
+				if idx >= 0 && idx < len(board.CurrentBoard) {
+					g.Word = board.CurrentBoard[idx]
+				}
+				g.Correct = g.Color == dto.CardColor(color)
+				if !g.Correct {
+					turn.Mistakes++
+				}
+				turn.Guesses = append(turn.Guesses, g)
+			}
+			t.Turns = append(t.Turns, turn)
+		}
+		if !added {
+			break
+		}
+	}
+
+	if gs.Winner != nil {
+		t.Reason = OutcomeAllCards
+		for _, idx := range board.AssassinIndexs {
+			if board.IsGuessed(idx) {
+				t.Reason = OutcomeAssassin
+			}
+		}
+	}
+
+	return t, nil
+}
+
+// markdownEscaper backslash-escapes characters that could start Markdown
+// syntax or end a table cell in user text. Line breaks become spaces, so the
+// text stays in its list item or cell.
+var markdownEscaper = strings.NewReplacer(
+	"\\", "\\\\", "`", "\\`", "*", "\\*", "_", "\\_", "~", "\\~",
+	"[", "\\[", "]", "\\]", "<", "\\<", ">", "\\>", "#", "\\#",
+	"|", "\\|", "!", "\\!", "\r", " ", "\n", " ",
+)
+
+func escapeMarkdown(s string) string {
+	return markdownEscaper.Replace(s)
+}
+
+// codeSpan quotes s as inline code. Backslashes are literal in code spans, so
+// the fence is made longer than any run of backticks in s instead.
+func codeSpan(s string) string {
+	s = strings.NewReplacer("\r", " ", "\n", " ").Replace(s)
+
+	longest, run := 0, 0
+	for _, r := range s {
+		if r == '`' {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	if strings.HasPrefix(s, "`") || strings.HasSuffix(s, "`") {
+		s = " " + s + " "
+	}
+	fence := strings.Repeat("`", longest+1)
+	return fence + s + fence
+}
+
+func playerNames(players []dto.GameStatePlayer) string {
+	names := make([]string, 0, len(players))
+	for _, p := range players {
+		names = append(names, escapeMarkdown(p.Name))
+	}
+	return strings.Join(names, ", ")
+}
+
+// Markdown renders the transcript for sharing in chats and issue trackers.
+func (t Transcript) Markdown() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# Codenames game %s\n\n", t.GameID)
+	fmt.Fprintf(&b, "- Status: %s\n", t.Status)
+	fmt.Fprintf(&b, "- Created: %s\n", t.CreatedAt.Format(time.RFC3339))
+	if t.StartedAt != nil {
+		fmt.Fprintf(&b, "- Started: %s\n", t.StartedAt.Format(time.RFC3339))
+	}
+
+	b.WriteString("\n## Outcome\n\n")
+	switch {
+	case t.Winner == nil:
+		b.WriteString("The game did not finish.\n")
+	case t.Reason == OutcomeAssassin:
+		fmt.Fprintf(&b, "**%s** won after the other team found the assassin.\n", *t.Winner)
+	default:
+		fmt.Fprintf(&b, "**%s** won by finding all of their cards.\n", *t.Winner)
+	}
+
+	b.WriteString("\n## Teams\n\n")
+	for _, team := range t.Teams {
+		spymaster := "none"
+		if team.Spymaster != nil {
+			spymaster = escapeMarkdown(team.Spymaster.Name)
+		}
+		fmt.Fprintf(&b, "- **%s**: spymaster %s; players %s; %d cards left\n", team.Color, spymaster, playerNames(team.Players), team.CardsLeft)
+	}
+
+	b.WriteString("\n## Clues\n\n")
+	if len(t.Turns) == 0 {
+		b.WriteString("No clues were given.\n")
+	}
+	for _, turn := range t.Turns {
+		fmt.Fprintf(&b, "%d. **%s**: %s %d", turn.Number, turn.Team, codeSpan(turn.Clue), turn.Count)
+		if len(turn.Guesses) == 0 {
+			b.WriteString(" (no guesses)\n")
+			continue
+		}
+
+		guesses := make([]string, 0, len(turn.Guesses))
+		for _, g := range turn.Guesses {
+			if g.Correct {
+				guesses = append(guesses, escapeMarkdown(g.Word))
+			} else {
+				guesses = append(guesses, fmt.Sprintf("~~%s~~ (%s)", escapeMarkdown(g.Word), g.Color))
+			}
+		}
+		fmt.Fprintf(&b, ": %s", strings.Join(guesses, ", "))
+		switch {
+		case turn.Mistakes == 1:
+			b.WriteString(" (1 mistake)")
+		case turn.Mistakes > 1:
+			fmt.Fprintf(&b, " (%d mistakes)", turn.Mistakes)
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("\n## Key\n\n")
+	cols := t.Size.X
+	if cols <= 0 {
+		cols = len(t.Cards)
+	}
+	b.WriteString("|" + strings.Repeat(" |", cols) + "\n")
+	b.WriteString("|" + strings.Repeat("---|", cols) + "\n")
+	for row := 0; row*cols < len(t.Cards); row++ {
+		b.WriteString("|")
+		for _, card := range t.Cards[row*cols : min((row+1)*cols, len(t.Cards))] {
+			cell := fmt.Sprintf("%s (%s)", escapeMarkdown(card.Word), card.Color)
+			if card.Guessed {
+				cell = "**" + cell + "**"
+			}
+			fmt.Fprintf(&b, " %s |", cell)
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("\nGuessed cards are in bold.\n")
+
+	return b.String()
+}
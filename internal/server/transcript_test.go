@@ -0,0 +1,94 @@
+package server
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/ninox14/gore-codenames/internal/database/dto"
+	"github.com/ninox14/gore-codenames/internal/database/sqlc"
+)
+
+func TestTranscriptInterleavesTurns(t *testing.T) {
+	gs, players := newTestGame(t)
+	// Blue gets a second card, so red guessing one does not end the game.
+	gs.Board.WordsByTeam[dto.TeamColorBlue] = []int{2, 3}
+
+	steps := []struct {
+		actor  dto.GameStatePlayer
+		action GameAction
+	}{
+		{players[0], GiveClueData{Word: "fruit", Number: 1}},
+		{players[1], GuessCardData{Index: 2}},
+		{players[2], GiveClueData{Word: "omelette", Number: 1}},
+		{players[3], GuessCardData{Index: 4}},
+	}
+	for _, s := range steps {
+		if err := s.action.Apply(gs, s.actor); err != nil {
+			t.Fatalf("%T: %v", s.action, err)
+		}
+	}
+
+	tr, err := NewTranscript(sqlc.Game{ID: uuid.New(), Status: sqlc.GameStatusFinished, GameState: gs})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(tr.Turns) != 2 || tr.Turns[0].Team != dto.TeamColorRed || tr.Turns[1].Team != dto.TeamColorBlue {
+		t.Fatalf("expected a red then a blue turn; got %+v", tr.Turns)
+	}
+	if tr.Turns[0].Mistakes != 1 || tr.Reason != OutcomeAssassin || *tr.Winner != dto.TeamColorRed {
+		t.Errorf("expected one red mistake and a red win by assassin; got %d, %s, %s", tr.Turns[0].Mistakes, tr.Reason, *tr.Winner)
+	}
+
+	md := tr.Markdown()
+	for _, want := range []string{"`fruit` 1: ~~cat~~ (blue) (1 mistake)", "**red** won after the other team found the assassin"} {
+		if !strings.Contains(md, want) {
+			t.Errorf("expected markdown to contain %q\n%s", want, md)
+		}
+	}
+}
+
+func TestTranscriptEscapesMarkdown(t *testing.T) {
+	gs, players := newTestGame(t)
+	gs.Board.CurrentBoard[2] = "c|a*t"
+	gs.Teams[dto.TeamColorRed].Players[1].Name = "[x](http://evil) _y_"
+
+	for _, s := range []struct {
+		actor  dto.GameStatePlayer
+		action GameAction
+	}{
+		{players[0], GiveClueData{Word: "a`b", Number: 1}},
+		{players[1], GuessCardData{Index: 2}},
+	} {
+		if err := s.action.Apply(gs, s.actor); err != nil {
+			t.Fatalf("%T: %v", s.action, err)
+		}
+	}
+
+	tr, err := NewTranscript(sqlc.Game{ID: uuid.New(), Status: sqlc.GameStatusFinished, GameState: gs})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	md := tr.Markdown()
+	for _, want := range []string{"``a`b`` 1", "~~c\\|a\\*t~~ (blue)", "\\[x\\](http://evil) \\_y\\_", "**c\\|a\\*t (blue)**"} {
+		if !strings.Contains(md, want) {
+			t.Errorf("expected markdown to contain %q\n%s", want, md)
+		}
+	}
+}
+
+func TestCodeSpan(t *testing.T) {
+	tests := map[string]string{
+		"fruit":  "`fruit`",
+		"a`b":    "``a`b``",
+		"`tick":  "`` `tick ``",
+		"a``b`c": "```a``b`c```",
+	}
+	for in, want := range tests {
+		if got := codeSpan(in); got != want {
+			t.Errorf("codeSpan(%q) = %q; want %q", in, got, want)
+		}
+	}
+}
@@ -3,13 +3,13 @@ package server
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"sync"
 	"time"
 
 	"github.com/coder/websocket"
-	"github.com/coder/websocket/wsjson"
 	"github.com/google/uuid"
 	"github.com/ninox14/gore-codenames/internal/database"
 	"github.com/ninox14/gore-codenames/internal/database/dto"
@@ -23,80 +23,22 @@ func (rpp RedisPlayersPath) MarshalBinary() ([]byte, error) {
 	return json.Marshal(rpp)
 }
 
+func (RedisPlayersPath) JSONSchemaEnum() []any {
+	return []any{SpectatorsPath, TeamRedPath, TeamBluePath}
+}
+
 const (
 	SpectatorsPath RedisPlayersPath = "spectators"
 	TeamRedPath    RedisPlayersPath = "teams.red.players"
 	TeamBluePath   RedisPlayersPath = "teams.blue.players"
 )
 
-type MessageType string
-
-const (
-	MsgJoinGame MessageType = "join_game"
-	// MsgLeaveGame MessageType = "leave_game"
-	MsgGameState  MessageType = "game_state"
-	MsgChangeTeam MessageType = "change_team"
-	// MsgPlayerJoined MessageType = "player_joined"
-	// MsgPlayerLeft   MessageType = "player_left"
-	MsgError MessageType = "error"
-)
-
-type ChangeTeamData struct {
-	Destination RedisPlayersPath `json:"destination"`
-}
-
-type Message struct {
-	Type   MessageType `json:"type"`
-	Data   any         `json:"data"`
-	GameID *uuid.UUID  `json:"game_id,omitempty"`
-}
-
-func (m *Message) UnmarshalJSON(data []byte) error {
-	// First unmarshal into a temporary struct to get the type
-	var temp struct {
-		Type   MessageType     `json:"type"`
-		Data   json.RawMessage `json:"data"`
-		GameID *uuid.UUID      `json:"game_id,omitempty"`
-	}
-
-	if err := json.Unmarshal(data, &temp); err != nil {
-		return err
-	}
-
-	m.Type = temp.Type
-	m.GameID = temp.GameID
-
-	if len(temp.Data) == 0 {
-		m.Data = nil
-		return nil
-	}
-
-	// Based on the type, unmarshal data into the correct struct
-	switch temp.Type {
-	case MsgChangeTeam:
-		var changeTeamData ChangeTeamData
-		if err := json.Unmarshal(temp.Data, &changeTeamData); err != nil {
-			return err
-		}
-		m.Data = changeTeamData
-	default:
-		// For other types, unmarshal as map[string]any
-		var genericData map[string]any
-		if err := json.Unmarshal(temp.Data, &genericData); err != nil {
-			return err
-		}
-		m.Data = genericData
-	}
-
-	return nil
-}
-
 type Player struct {
-	ID       uuid.UUID       `json:"id"`
-	Name     string          `json:"name"`
-	Conn     *websocket.Conn `json:"-"`
-	GameID   uuid.UUID       `json:"-"`
-	LastSeen time.Time       `json:"-"`
+	ID       uuid.UUID `json:"id"`
+	Name     string    `json:"name"`
+	*Client  `json:"-"`
+	GameID   uuid.UUID `json:"-"`
+	LastSeen time.Time `json:"-"`
 }
 
 func GameHubPlayerToGameStatePlayer(p *Player) dto.GameStatePlayer {
@@ -106,15 +48,24 @@ func GameHubPlayerToGameStatePlayer(p *Player) dto.GameStatePlayer {
 	}
 }
 
+// Game tracks the players of one game connected to this node. Broadcasts
+// reach players on other nodes through the game's Redis channel.
 type Game struct {
 	ID      uuid.UUID
 	Players map[uuid.UUID]*Player
 	mu      sync.RWMutex
 	hub     *GameHub
+	sub     *redis.PubSub
+}
+
+func (g *Game) playerCount() int {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return len(g.Players)
 }
 
 func (g *Game) GetRedisGameKey() string {
-	return fmt.Sprintf("game:%s", g.ID)
+	return GetRedisGameKey(g.ID)
 }
 
 func NewGame(id uuid.UUID, hub *GameHub) *Game {
@@ -142,234 +93,246 @@ func (g *Game) GetGameHubPlayer(playerId uuid.UUID) *Player {
 	return player
 }
 
-func (g *Game) GetGameStateFromRedis(ctx context.Context) dto.GameState {
-	gameKey := g.GetRedisGameKey()
-	gameState, err := g.hub.rdb.JSONGet(ctx, gameKey, "$").Result()
-
-	if err != nil {
-		errStr := "Could not retrieve game state for game from redis"
-		g.hub.logger.Error(errStr, "gameId", g.ID, "err", err)
-		g.broadcastErrorMessage(ctx, errStr, err)
-		panic(errStr)
-	}
+func (g *Game) GetGameStateFromRedis(ctx context.Context) (dto.GameState, error) {
+	return g.hub.GetGameState(ctx, g.ID)
+}
 
-	var gs []dto.GameState
-	err = json.Unmarshal([]byte(gameState), &gs)
-	if err != nil || len(gs) < 1 {
-		errStr := "Could not unmarshal game state"
-		g.hub.logger.Error(errStr, "gameId", g.ID, "err", err)
-		g.broadcastErrorMessage(ctx, errStr, err)
-		panic(errStr)
+func (p *Player) send(ctx context.Context, msg Message) error {
+	if p.Client == nil {
+		return nil
 	}
-	return gs[0]
+	return p.Client.Send(ctx, msg)
 }
 
 func (g *Game) broadcast(ctx context.Context, msg Message) {
-	for _, player := range g.Players {
-		if player.Conn != nil {
-			if err := wsjson.Write(ctx, player.Conn, msg); err != nil {
-				g.hub.logger.Error("Error broadcasting to player", "player", player.ID, "error", err)
-				// Remove player if connection is broken
-				go g.RemovePlayer(ctx, player.ID)
-			}
-		}
-	}
+	g.broadcastTo(ctx, msg, nil)
+}
+
+// broadcastTo sends msg to the given players on whichever node they are
+// connected to, or to everyone when recipients is nil.
+func (g *Game) broadcastTo(ctx context.Context, msg Message, recipients []uuid.UUID) {
+	g.publish(ctx, msg, recipients)
 }
 
 func (g *Game) broadcastErrorMessage(ctx context.Context, msg string, err error) {
 	g.hub.logger.Error(msg, "error", err)
 
-	data := struct {
-		Message string `json:"message"`
-		Err     string `json:"err"`
-	}{Message: msg, Err: err.Error()}
-	g.broadcast(ctx, Message{Type: MsgError, Data: data})
+	g.broadcast(ctx, Message{Type: MsgError, Data: ErrorData{Message: msg, Err: err.Error()}})
 }
 
 func (g *Game) broadcastGameState(ctx context.Context) {
-	gameState := g.GetGameStateFromRedis(ctx)
+	gameState, err := g.GetGameStateFromRedis(ctx)
+	if err != nil {
+		g.hub.logger.Error("Could not retrieve game state for game from redis", "gameId", g.ID, "err", err)
+		return
+	}
 
-	g.broadcast(ctx, Message{
-		Type: MsgGameState,
-		Data: gameState,
-	})
+	g.broadcastState(ctx, gameState)
+}
+
+// broadcastState sends the state to everyone in the game and tells waiting
+// players where they stand instead.
+func (g *Game) broadcastState(ctx context.Context, gs dto.GameState) {
+	g.broadcastTo(ctx, Message{Type: MsgGameState, Data: gs}, stateRecipients(&gs))
+	if len(gs.Waitlist) > 0 {
+		data := WaitlistData{Waitlist: gs.Waitlist, MaxSpectators: gs.Settings.MaxSpectators}
+		g.broadcastTo(ctx, Message{Type: MsgWaitlist, GameID: &g.ID, Data: data}, waitlistIDs(&gs))
+	}
+	g.publishSpectatorEvent(ctx, MsgGameState, gs.Redacted())
 }
 
 func (g *Game) AddPlayer(ctx context.Context, player Player) {
 	g.mu.Lock()
-	defer g.mu.Unlock()
 	player.GameID = g.ID
 	player.LastSeen = time.Now()
 
 	g.Players[player.ID] = &player
+	g.mu.Unlock()
 
-	err := g.AddPlayerToGS(ctx, SpectatorsPath, &player)
-	if err != nil {
-		g.hub.logger.Error("Error adding player to reddis state", "err", err)
-		g.broadcastErrorMessage(ctx, "Error adding player to reddis state", err)
+	if err := g.hub.ensureGameState(ctx, g.ID); err != nil {
+		g.hub.logger.Error("Could not load game state", "gameId", g.ID, "err", err)
+		player.send(ctx, Message{Type: MsgError, GameID: &g.ID, Data: ErrorData{Message: "Could not join game", Err: err.Error()}})
+
+		g.mu.Lock()
+		delete(g.Players, player.ID)
+		g.mu.Unlock()
+		g.hub.RemoveGame(g.ID)
 		return
 	}
+
 	// Broadcast updated game state to all players in lobby
-	g.broadcastGameState(ctx)
+	if err := g.ApplyAction(ctx, player.ID, JoinAction{}, nil); err != nil {
+		g.closePlayer(player.ID, err.Error())
+		return
+	}
+	g.hub.playerConnected(ctx, g.ID, player.ID)
+	// The host may have left while nobody was around to take over.
+	g.hub.scheduleHostMigration(g.ID)
+	g.sendChatHistory(ctx, &player)
 }
 
-func (g *Game) ChangePlayerTeam(ctx context.Context, playerId uuid.UUID, changeTeamData ChangeTeamData) {
-	player := g.GetGameHubPlayer(playerId)
-
-	if player == nil {
+func (g *Game) ChangePlayerTeam(ctx context.Context, playerId uuid.UUID, changeTeamData ChangeTeamData, expectedVersion *int64) {
+	if g.GetGameHubPlayer(playerId) == nil {
 		g.broadcastErrorMessage(ctx, "Trying to move non existant player", fmt.Errorf("Player %s doesnt exist in game %s", playerId, g.ID))
 		return
 	}
 
-	err := g.ChangePlayerTeamGS(ctx, player, changeTeamData.Destination)
+	g.ApplyAction(ctx, playerId, changeTeamData, expectedVersion)
+}
 
-	if err != nil {
-		g.broadcastErrorMessage(ctx, "Could not change team for player in game state", err)
-		return
+// ApplyAction applies a player's action to the game state as a
+// compare-and-set and broadcasts the result. Rejected actions are reported to
+// the player only, the error is returned for callers with follow-up work.
+func (g *Game) ApplyAction(ctx context.Context, playerID uuid.UUID, action GameAction, expectedVersion *int64) error {
+	player := g.GetGameHubPlayer(playerID)
+	if player == nil {
+		g.hub.logger.Error("Action from player not in game", "player", playerID, "gameId", g.ID)
+		return ErrPlayerNotInGame
 	}
 
-	g.broadcastGameState(ctx)
+	err := g.apply(ctx, GameHubPlayerToGameStatePlayer(player), action, expectedVersion)
+	if err != nil {
+		g.hub.logger.Debug("Rejected game action", "player", playerID, "action", fmt.Sprintf("%T", action), "err", err)
+		player.send(ctx, Message{Type: MsgError, GameID: &g.ID, Data: ErrorData{Message: "Could not apply action", Err: err.Error()}})
+	}
+	return err
 }
 
-func (g *Game) RemovePlayer(ctx context.Context, playerID uuid.UUID) {
-	g.mu.Lock()
-	defer g.mu.Unlock()
-
-	if player, exists := g.Players[playerID]; exists {
-		// TODO: also remove from GS in redis
-		// Handle disconnected state for players;
-		// Disconnected field on player in redis state?
-		delete(g.Players, playerID)
-
-		// Notify other players that	 this player left
-		// TODO: Send correct data
-		g.broadcastGameState(ctx)
-		// g.broadcast(ctx, Message{
-		// 	Type: MsgPlayerLeft,
-		// 	Data: "PlayerRemoved",
-		// })
-
-		// Close the connection
-		if player.Conn != nil {
-			player.Conn.Close(websocket.StatusNormalClosure, "Player Left the game")
+// apply runs an action on behalf of actor, who does not need to be connected
+// to this node, then records, persists and broadcasts the result.
+func (g *Game) apply(ctx context.Context, actor dto.GameStatePlayer, action GameAction, expectedVersion *int64) error {
+	var before dto.GameState
+	gs, err := g.hub.UpdateGameState(ctx, g.ID, expectedVersion, func(gs *dto.GameState) error {
+		before = *gs
+		if gs.Turn != nil {
+			turn := *gs.Turn
+			before.Turn = &turn
 		}
+		return action.Apply(gs, actor)
+	})
+	if errors.Is(err, ErrNoChange) {
+		// Still broadcast so a rejoining player gets the current state.
+		err = nil
+	}
+	if err != nil {
+		return err
+	}
 
-		// If lobby is empty, remove it
-		if len(g.Players) == 0 {
-			g.hub.RemoveGame(g.ID)
-		}
+	if gs.Version != before.Version {
+		g.recordEvent(ctx, gs.Version, actor, action)
 	}
+
+	if isTransition(&before, &gs) {
+		g.hub.persister.persistNow(g.ID)
+	} else if gs.Version != before.Version {
+		g.hub.persister.markDirty(g.ID)
+	}
+
+	if gs.HostID != before.HostID {
+		g.hub.saveGameHost(ctx, g.ID, gs.HostID)
+		g.broadcast(ctx, Message{Type: MsgHostChanged, GameID: &g.ID, Data: HostChangedData{HostID: gs.HostID, PreviousHostID: before.HostID}})
+	}
+
+	g.broadcastState(ctx, gs)
+	return nil
 }
 
-// AddPlayerToGS checks in Redis with JSONPath, then adds
-func (g *Game) AddPlayerToGS(ctx context.Context, path RedisPlayersPath, player *Player) error {
-	// TODO: add getter for viable paths if more than two teams
-	paths := []RedisPlayersPath{SpectatorsPath, TeamBluePath, TeamRedPath}
-	key := g.GetRedisGameKey()
-	for _, p := range paths {
-		// Query directly in Redis
-		jsonPath := fmt.Sprintf("$.%s[?(@.id==\"%s\")]", p, player.ID)
-
-		res, err := g.hub.rdb.JSONGet(ctx, key, jsonPath).Result()
-		if err != nil && err != redis.Nil {
-			return err
-		}
-		if res != "[]" && res != "null" {
-			// Do nothing cause player player already in lobby
-			g.hub.logger.Info("Player already exists in", "player", player.ID, "path", p, "jsonGet", res)
-			return nil
-		}
+// RemovePlayer drops a local player. Only the map is changed under the game
+// lock; Redis and the hub are called afterwards, as RemoveGame locks the hub
+// before the game.
+func (g *Game) RemovePlayer(ctx context.Context, playerID uuid.UUID) {
+	g.mu.Lock()
+	player, exists := g.Players[playerID]
+	delete(g.Players, playerID)
+	empty := len(g.Players) == 0
+	g.mu.Unlock()
+
+	if !exists {
+		return
 	}
 
-	// Append only if not found
-	jsonPlayer, err := json.Marshal(GameHubPlayerToGameStatePlayer(player))
-	if err != nil {
-		return err
+	// TODO: Disconnected field on seated players in redis state?
+	g.hub.playerDisconnected(ctx, g.ID, playerID)
+
+	// Broadcasts the state either way, telling others this player left.
+	actor := GameHubPlayerToGameStatePlayer(player)
+	if err := g.apply(ctx, actor, LeaveAction{}, nil); err != nil {
+		g.hub.logger.Error("Could not free seat of leaving player", "gameId", g.ID, "player", playerID, "err", err)
 	}
 
-	_, err = g.hub.rdb.JSONArrAppend(ctx, key, "$."+string(path), jsonPlayer).Result()
+	// Close the connection
+	if player.Client != nil {
+		player.Conn.Close(websocket.StatusNormalClosure, "Player Left the game")
+	}
 
-	return err
+	// If lobby is empty, remove it
+	if empty {
+		g.hub.RemoveGame(g.ID)
+	}
 }
 
-func (g *Game) ChangePlayerTeamGS(ctx context.Context, player *Player, destPath RedisPlayersPath) error {
-	script := redis.NewScript(`
-	local key          = KEYS[1]
-	local playerId     = ARGV[1]
-	local playerJson   = ARGV[2]
-	local destination  = ARGV[3]
-
-	-- Always remove player from spectators
-	redis.call("JSON.DEL", key, string.format("$.spectators[?(@.id == '%s')]", playerId))
-
-	-- Remove from red team players
-	redis.call("JSON.DEL", key, string.format("$.teams.red.players[?(@.id == '%s')]", playerId))
-
-	-- Remove from blue team players
-	redis.call("JSON.DEL", key, string.format("$.teams.blue.players[?(@.id == '%s')]", playerId))
-
-	-- Remove captain role if player was captain
-	local redCaptain = redis.call("JSON.GET", key, "$.teams.red.captain_id")
-	if redCaptain ~= nil and redCaptain ~= "null" then
-		redCaptain = string.sub(redCaptain, 2, -2) -- strip quotes
-		if redCaptain == playerId then
-				redis.call("JSON.SET", key, "$.teams.red.captain_id", "null")
-		end
-	end
-
-	local blueCaptain = redis.call("JSON.GET", key, "$.teams.blue.captain_id")
-	if blueCaptain ~= nil and blueCaptain ~= "null" then
-		blueCaptain = string.sub(blueCaptain, 2, -2) -- strip quotes
-		if blueCaptain == playerId then
-				redis.call("JSON.SET", key, "$.teams.blue.captain_id", "null")
-		end
-	end
-
-	-- Append to destination array
-	if string.sub(destination, 1, 1) == '"' then
-		destination = string.sub(destination, 2, -2)
-	end
-
-	local destPath = "$." .. destination
-	redis.call("JSON.ARRAPPEND", key, destPath, playerJson)
-
-	return true`)
-
-	playerJSON, _ := json.Marshal(player)
-
-	_, err := script.Run(ctx, g.hub.rdb, []string{g.GetRedisGameKey()},
-		player.ID.String(),
-		string(playerJSON),
-		destPath, // e.g. ".teams.red.players"
-	).Result()
+type GameHub struct {
+	games     map[uuid.UUID]*Game
+	mu        sync.RWMutex
+	logger    *slog.Logger
+	db        *database.DB
+	rdb       *redis.Client
+	limiters  *limiterRegistry
+	persister *persister
+	config    GameHubConfig
+}
 
-	return err
+func NewGameHub(logger *slog.Logger, db *database.DB, rdb *redis.Client, config GameHubConfig) *GameHub {
+	h := &GameHub{games: make(map[uuid.UUID]*Game), logger: logger, db: db, rdb: rdb, limiters: newLimiterRegistry(), config: config}
+	h.persister = newPersister(h)
+	return h
 }
 
-type GameHub struct {
-	games  map[uuid.UUID]*Game
-	mu     sync.RWMutex
-	logger *slog.Logger
-	db     *database.DB
-	rdb    *redis.Client
+func GetRedisGameKey(gameID uuid.UUID) string {
+	return fmt.Sprintf("game:%s", gameID)
 }
 
-func NewGameHub(logger *slog.Logger, db *database.DB, rdb *redis.Client) *GameHub {
-	return &GameHub{games: make(map[uuid.UUID]*Game), logger: logger, db: db, rdb: rdb}
+// GetGameState reads a game's state from Redis without requiring local
+// players, e.g. for HTTP handlers.
+func (h *GameHub) GetGameState(ctx context.Context, gameID uuid.UUID) (dto.GameState, error) {
+	gameState, err := h.rdb.JSONGet(ctx, GetRedisGameKey(gameID), "$").Result()
+	if errors.Is(err, redis.Nil) || (err == nil && gameState == "") {
+		return dto.GameState{}, missingState(gameID)
+	}
+	if err != nil {
+		return dto.GameState{}, err
+	}
+
+	return decodeGameState(gameState)
 }
 
-func (h *GameHub) GetOrCreateGame(gameId uuid.UUID) *Game {
+// GetOrCreateGame returns the local game, creating it and subscribing to its
+// broadcasts first. The subscription waits on Redis, so it is made without the
+// hub lock, and a game that could not subscribe is not kept.
+func (h *GameHub) GetOrCreateGame(ctx context.Context, gameId uuid.UUID) (*Game, error) {
+	if game := h.GetGame(gameId); game != nil {
+		return game, nil
+	}
+
+	game := NewGame(gameId, h)
+	if err := game.subscribe(ctx); err != nil {
+		if game.sub != nil {
+			game.sub.Close()
+		}
+		return nil, err
+	}
+
 	h.mu.Lock()
 	defer h.mu.Unlock()
 
-	if game, exists := h.games[gameId]; exists {
-		return game
+	// Another connection may have created the game while subscribing.
+	if existing, exists := h.games[gameId]; exists {
+		game.sub.Close()
+		return existing, nil
 	}
-
-	game := NewGame(gameId, h)
 	h.games[gameId] = game
 	h.logger.Debug("Created new lobby:", "gameId", gameId)
-	return game
+	return game, nil
 }
 
 func (gh *GameHub) GetGame(gameId uuid.UUID) *Game {
@@ -378,11 +341,17 @@ func (gh *GameHub) GetGame(gameId uuid.UUID) *Game {
 	return gh.games[gameId]
 }
 
+// RemoveGame forgets a game once its last local player is gone. It takes the
+// hub lock and then the game's, so callers must not hold a game lock.
 func (h *GameHub) RemoveGame(gameID uuid.UUID) {
 	h.mu.Lock()
 	defer h.mu.Unlock()
-	// TODO: Delete game from redis as well
-	if game, exists := h.games[gameID]; exists && len(game.Players) == 0 {
+	// Redis keys outlive the local game, other nodes may still have players.
+	// They expire or are archived by the janitor.
+	if game, exists := h.games[gameID]; exists && game.playerCount() == 0 {
+		if game.sub != nil {
+			game.sub.Close()
+		}
 		delete(h.games, gameID)
 		h.logger.Debug("Removed empty game", "gameId", gameID)
 	}
@@ -415,14 +384,49 @@ func websocketPingLoop(ctx context.Context, c *websocket.Conn, userId uuid.UUID,
 	}
 }
 
-func processWSMessage(ctx context.Context, msg *Message, c *websocket.Conn, user sqlc.User, hub *GameHub) {
+func writeErrorMessage(ctx context.Context, c *Client, msg string, err error) {
+	c.Send(ctx, Message{Type: MsgError, Data: ErrorData{Message: msg, Err: err.Error()}})
+}
+
+func processWSMessage(ctx context.Context, msg *Message, c *Client, user sqlc.User, hub *GameHub) {
+	if data, ok := msg.Data.(JoinGameData); ok && msg.Type == MsgJoinGame && data.Code != "" {
+		game, err := hub.ResolveJoinCode(ctx, data.Code)
+		if err != nil {
+			writeErrorMessage(ctx, c, "Unknown room code", ErrInvalidJoinCode)
+			return
+		}
+		msg.GameID = &game.ID
+	}
+
+	if msg.GameID == nil {
+		writeErrorMessage(ctx, c, "Missing game id", fmt.Errorf("%s requires game_id", msg.Type))
+		return
+	}
+	if *msg.GameID != c.GameID {
+		writeErrorMessage(ctx, c, "Not admitted to this game", ErrNotAdmitted)
+		return
+	}
+
 	switch msg.Type {
 	case MsgJoinGame:
-		game := hub.GetOrCreateGame(*msg.GameID)
-		player := Player{ID: user.ID, Name: user.Name, Conn: c, GameID: *msg.GameID, LastSeen: time.Now()}
+		if err := hub.checkExcluded(ctx, *msg.GameID, user.ID); err != nil {
+			writeErrorMessage(ctx, c, "Could not join game", err)
+			return
+		}
+		game, err := hub.GetOrCreateGame(ctx, *msg.GameID)
+		if err != nil {
+			hub.logger.Error("Could not subscribe to game broadcasts", "gameId", *msg.GameID, "err", err)
+			writeErrorMessage(ctx, c, "Could not join game", err)
+			return
+		}
+		player := Player{ID: user.ID, Name: user.Name, Client: c, GameID: *msg.GameID, LastSeen: time.Now()}
 		game.AddPlayer(ctx, player)
 	case MsgChangeTeam:
-		game := hub.GetOrCreateGame(*msg.GameID)
+		game, err := hub.GetOrCreateGame(ctx, *msg.GameID)
+		if err != nil {
+			writeErrorMessage(ctx, c, "Could not change team", err)
+			return
+		}
 		movePlayerData, ok := msg.Data.(ChangeTeamData)
 
 		if !ok {
@@ -430,8 +434,59 @@ func processWSMessage(ctx context.Context, msg *Message, c *websocket.Conn, user
 			return
 		}
 
-		game.ChangePlayerTeam(ctx, user.ID, movePlayerData)
+		game.ChangePlayerTeam(ctx, user.ID, movePlayerData, msg.Version)
+	case MsgChatMessage:
+		game := hub.GetGame(*msg.GameID)
+		chatData, ok := msg.Data.(ChatMessageData)
+
+		if game == nil || !ok {
+			writeErrorMessage(ctx, c, "Invalid chat message", fmt.Errorf("not in game %s or bad data %T", msg.GameID, msg.Data))
+			return
+		}
+
+		game.SendChatMessage(ctx, user.ID, chatData)
+	case MsgShuffleTeams:
+		game := hub.GetGame(*msg.GameID)
+		data, ok := msg.Data.(ShuffleTeamsData)
+
+		if game == nil || !ok {
+			writeErrorMessage(ctx, c, "Invalid game action", fmt.Errorf("not in game %s or bad data %T", msg.GameID, msg.Data))
+			return
+		}
+
+		game.ShuffleTeams(ctx, user.ID, data, msg.Version)
+	case MsgStartReadyCheck:
+		game := hub.GetGame(*msg.GameID)
+		data, ok := msg.Data.(StartReadyCheckData)
+
+		if game == nil || !ok {
+			writeErrorMessage(ctx, c, "Invalid game action", fmt.Errorf("not in game %s or bad data %T", msg.GameID, msg.Data))
+			return
+		}
+
+		game.StartReadyCheck(ctx, user.ID, data, msg.Version)
+	case MsgKickPlayer, MsgBanPlayer:
+		game := hub.GetGame(*msg.GameID)
+		action, ok := msg.Data.(GameAction)
+
+		if game == nil || !ok {
+			writeErrorMessage(ctx, c, "Invalid moderation action", fmt.Errorf("not in game %s or bad data %T", msg.GameID, msg.Data))
+			return
+		}
+
+		game.Moderate(ctx, user.ID, action)
+	case MsgSetCaptain, MsgStartGame, MsgGiveClue, MsgGuessCard, MsgEndTurn, MsgTransferHost, MsgBalanceTeams,
+		MsgSetReady, MsgCancelReadyCheck:
+		game := hub.GetGame(*msg.GameID)
+		action, ok := msg.Data.(GameAction)
+
+		if game == nil || !ok {
+			writeErrorMessage(ctx, c, "Invalid game action", fmt.Errorf("not in game %s or bad data %T", msg.GameID, msg.Data))
+			return
+		}
+
+		game.ApplyAction(ctx, user.ID, action, msg.Version)
 	default:
-		wsjson.Write(ctx, c, msg)
+		c.Send(ctx, *msg)
 	}
 }
@@ -0,0 +1,175 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/ninox14/gore-codenames/internal/database/dto"
+	"github.com/ninox14/gore-codenames/internal/request"
+	"github.com/ninox14/gore-codenames/internal/response"
+	"github.com/ninox14/gore-codenames/internal/validator"
+	"golang.org/x/text/cases"
+)
+
+const (
+	// MaxCardRunes is about what fits on a card at the normal font size.
+	MaxCardRunes = 12
+	// Boards are square, from the default size up to MaxBoardSide.
+	MaxBoardSide = 8
+)
+
+// WordpackStats describe the words of a pack. Lengths are in characters.
+type WordpackStats struct {
+	WordCount          int         `json:"word_count"`
+	UsableCount        int         `json:"usable_count"`
+	MultiWordCount     int         `json:"multi_word_count"`
+	MinLength          int         `json:"min_length"`
+	MaxLength          int         `json:"max_length"`
+	MeanLength         float64     `json:"mean_length"`
+	LengthDistribution map[int]int `json:"length_distribution"`
+	// LargestBoard is nil when there are too few usable words for any board.
+	LargestBoard *dto.BoardSize `json:"largest_board"`
+}
+
+// WordpackReport lists problems by word, keyed words[i]. Errors make a pack
+// unusable, warnings point out words that may play badly.
+type WordpackReport struct {
+	Valid    bool                `json:"valid"`
+	Errors   validator.Validator `json:"errors"`
+	Warnings validator.Validator `json:"warnings"`
+	Stats    WordpackStats       `json:"stats"`
+}
+
+func wordKey(i int) string {
+	return fmt.Sprintf("words[%d]", i)
+}
+
+// largestBoard returns the biggest square board n words fill.
+func largestBoard(n int) *dto.BoardSize {
+	var best *dto.BoardSize
+	for side := GetDefaultBoardSize().X; side <= MaxBoardSide && side*side <= n; side++ {
+		best = &dto.BoardSize{X: side, Y: side}
+	}
+	return best
+}
+
+// checkWords reports on words as they would be stored.
+func checkWords(words []string) WordpackReport {
+	var report WordpackReport
+	stats := WordpackStats{WordCount: len(words), LengthDistribution: map[int]int{}}
+
+	fold := cases.Fold()
+	folded := make([]string, len(words))
+	index := make(map[string]int, len(words))
+	totalLength := 0
+
+	for i, word := range words {
+		key := wordKey(i)
+		length := utf8.RuneCountInString(word)
+
+		switch {
+		case !validator.NotBlank(word):
+			report.Errors.AddFieldError(key, "Must not be blank")
+			continue
+		case !utf8.ValidString(word):
+			report.Errors.AddFieldError(key, "Must be valid UTF-8 text")
+			continue
+		case strings.ContainsFunc(word, unicode.IsControl):
+			report.Errors.AddFieldError(key, "Must not contain control characters")
+			continue
+		case length > MaxWordRunes:
+			report.Errors.AddFieldError(key, fmt.Sprintf("Must not be more than %d characters long", MaxWordRunes))
+			continue
+		}
+
+		// Words differing only in case or Unicode form are the same card.
+		folded[i] = fold.String(normalizeWord(word))
+		if j, ok := index[folded[i]]; ok {
+			report.Errors.AddFieldError(key, fmt.Sprintf("Duplicates %s (%q)", wordKey(j), words[j]))
+			continue
+		}
+		index[folded[i]] = i
+
+		if strings.ContainsFunc(strings.TrimSpace(word), unicode.IsSpace) {
+			report.Warnings.AddFieldError(key, "Has more than one word")
+			stats.MultiWordCount++
+		}
+		if length > MaxCardRunes {
+			report.Warnings.AddFieldError(key, fmt.Sprintf("May not fit on a card, which holds about %d characters", MaxCardRunes))
+		}
+
+		stats.UsableCount++
+		stats.LengthDistribution[length]++
+		totalLength += length
+		if stats.MinLength == 0 || length < stats.MinLength {
+			stats.MinLength = length
+		}
+		stats.MaxLength = max(stats.MaxLength, length)
+	}
+
+	// Looking up every part of every word keeps this linear in the number of
+	// words, as words are short.
+	for i, word := range folded {
+		if word == "" || index[word] != i {
+			continue
+		}
+		bounds := []int{}
+		for at := range word {
+			bounds = append(bounds, at)
+		}
+		bounds = append(bounds, len(word))
+
+		for a, start := range bounds {
+			for _, end := range bounds[a+1:] {
+				if start == 0 && end == len(word) {
+					continue
+				}
+				if j, ok := index[word[start:end]]; ok {
+					report.Warnings.AddFieldError(wordKey(j), fmt.Sprintf("Is part of %s (%q)", wordKey(i), words[i]))
+				}
+			}
+		}
+	}
+
+	if stats.UsableCount > 0 {
+		stats.MeanLength = float64(totalLength) / float64(stats.UsableCount)
+	}
+	stats.LargestBoard = largestBoard(stats.UsableCount)
+
+	minWords := MinImportWords()
+	report.Errors.CheckField(stats.LargestBoard != nil, "words", fmt.Sprintf("Must have at least %d usable words to fill a board, %d are usable", minWords, stats.UsableCount))
+
+	report.Stats = stats
+	report.Valid = !report.Errors.HasErrors()
+	return report
+}
+
+// validateWordpack reports on a pack without storing it.
+func (s *Server) validateWordpack(w http.ResponseWriter, r *http.Request) {
+	var input WordpackInput
+	err := request.DecodeJSON(w, r, &input)
+	if err != nil {
+		s.badRequest(w, r, err)
+		return
+	}
+	if input.Words == nil {
+		s.badRequest(w, r, errors.New("body must contain words"))
+		return
+	}
+	input.normalize()
+
+	report := checkWords(input.Words)
+	if input.Name != "" || input.Description != "" {
+		input.validate(&report.Errors)
+		report.Valid = !report.Errors.HasErrors()
+	}
+
+	err = response.JSON(w, http.StatusOK, report)
+	if err != nil {
+		s.serverError(w, r, err)
+	}
+}
@@ -0,0 +1,40 @@
+package server
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestCheckWords(t *testing.T) {
+	words := []string{"fast", "breakfast", "Fast", "ice cream", "", "supercalifragilistic"}
+	for i := len(words); i < 30; i++ {
+		words = append(words, fmt.Sprintf("word%c", 'a'+i))
+	}
+
+	report := checkWords(words)
+	if !report.Errors.HasErrors() || report.Valid {
+		t.Fatalf("expected errors for the duplicate and blank words")
+	}
+	for _, key := range []string{"words[2]", "words[4]"} {
+		if _, ok := report.Errors.FieldErrors[key]; !ok {
+			t.Errorf("expected an error for %s; got %v", key, report.Errors.FieldErrors)
+		}
+	}
+	for _, key := range []string{"words[0]", "words[3]", "words[5]"} {
+		if _, ok := report.Warnings.FieldErrors[key]; !ok {
+			t.Errorf("expected a warning for %s; got %v", key, report.Warnings.FieldErrors)
+		}
+	}
+
+	stats := report.Stats
+	if stats.WordCount != 30 || stats.UsableCount != 28 || stats.MultiWordCount != 1 {
+		t.Errorf("unexpected counts %+v", stats)
+	}
+	if stats.LargestBoard == nil || stats.LargestBoard.X != 5 {
+		t.Errorf("expected 28 words to fill a 5x5 board; got %+v", stats.LargestBoard)
+	}
+
+	if report := checkWords(words[:10]); report.Stats.LargestBoard != nil || report.Errors.FieldErrors["words"] == "" {
+		t.Errorf("expected too few words to be an error")
+	}
+}
@@ -0,0 +1,398 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/ninox14/gore-codenames/internal/response"
+	"github.com/ninox14/gore-codenames/internal/validator"
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+)
+
+// WordpackFormat is a file format wordpacks are imported from and exported to.
+type WordpackFormat string
+
+const (
+	WordpackFormatText WordpackFormat = "txt"
+	WordpackFormatCSV  WordpackFormat = "csv"
+	WordpackFormatJSON WordpackFormat = "json"
+)
+
+const (
+	MaxImportBytes = 1_048_576
+	MaxWordRunes   = 32
+)
+
+var (
+	ErrUnknownWordpackFormat = errors.New("format must be txt, csv or json")
+	ErrTooManyColumns        = errors.New("expected a word and an optional category")
+)
+
+// Reasons a line of an import is rejected.
+var (
+	rejectInvalid   = "not valid UTF-8 text"
+	rejectControl   = "contains control characters"
+	rejectTooLong   = fmt.Sprintf("longer than %d characters", MaxWordRunes)
+	rejectDuplicate = "duplicate"
+)
+
+// MinImportWords is how many words an import needs to fill the default board.
+func MinImportWords() int {
+	size := GetDefaultBoardSize()
+	return size.X * size.Y
+}
+
+// RejectedLine is an entry left out of an import. Line counts from 1, and
+// means the element of the words array for JSON documents.
+type RejectedLine struct {
+	Line   int    `json:"line"`
+	Text   string `json:"text"`
+	Reason string `json:"reason"`
+}
+
+// ImportedWords is the result of parsing an import before it is stored.
+// WordCategories holds the CSV category of each kept word that had one, and
+// Categories counts the words per category for the report.
+type ImportedWords struct {
+	Name           string
+	Description    string
+	Words          []string
+	Rejected       []RejectedLine
+	WordCategories map[string]string
+	Categories     map[string]int
+}
+
+// wordCollector normalises words and drops the ones that cannot be played.
+type wordCollector struct {
+	ImportedWords
+	seen map[string]bool
+	fold cases.Caser
+}
+
+func newWordCollector() *wordCollector {
+	return &wordCollector{
+		ImportedWords: ImportedWords{Words: []string{}, Rejected: []RejectedLine{}},
+		seen:          make(map[string]bool),
+		fold:          cases.Fold(),
+	}
+}
+
+// normalizeWord composes Unicode and collapses whitespace, so words typed on
+// different keyboards or copied from spreadsheets compare equal.
+func normalizeWord(raw string) string {
+	return strings.Join(strings.Fields(norm.NFC.String(raw)), " ")
+}
+
+// add reports whether the word was kept. Blank entries are skipped silently.
+func (c *wordCollector) add(line int, raw string) bool {
+	if !utf8.ValidString(raw) {
+		c.reject(line, strings.ToValidUTF8(raw, "�"), rejectInvalid)
+		return false
+	}
+
+	word := normalizeWord(raw)
+	switch {
+	case word == "":
+		return false
+	case strings.ContainsFunc(word, unicode.IsControl):
+		c.reject(line, raw, rejectControl)
+		return false
+	case utf8.RuneCountInString(word) > MaxWordRunes:
+		c.reject(line, raw, rejectTooLong)
+		return false
+	}
+
+	// Duplicates differing only in case would be the same card.
+	key := c.fold.String(word)
+	if c.seen[key] {
+		c.reject(line, raw, rejectDuplicate)
+		return false
+	}
+	c.seen[key] = true
+	c.Words = append(c.Words, word)
+	return true
+}
+
+func (c *wordCollector) reject(line int, text, reason string) {
+	c.Rejected = append(c.Rejected, RejectedLine{Line: line, Text: text, Reason: reason})
+}
+
+// parseWordList reads words in the given format. Lines starting with # are
+// comments in plain text, unless escaped with a backslash as exportWordpack
+// does. A first CSV row with a "word" column is taken as the header.
+func parseWordList(format WordpackFormat, body io.Reader) (ImportedWords, error) {
+	c := newWordCollector()
+
+	// Spreadsheets like to start their exports with a byte order mark.
+	r := bufio.NewReader(body)
+	if bom, _ := r.Peek(3); bytes.Equal(bom, []byte("\xef\xbb\xbf")) {
+		r.Discard(len(bom))
+	}
+
+	switch format {
+	case WordpackFormatText:
+		scanner := bufio.NewScanner(r)
+		for line := 1; scanner.Scan(); line++ {
+			text := strings.TrimSpace(scanner.Text())
+			if strings.HasPrefix(text, "#") {
+				continue
+			}
+			c.add(line, strings.TrimPrefix(text, `\`))
+		}
+		if err := scanner.Err(); err != nil {
+			return ImportedWords{}, err
+		}
+	case WordpackFormatCSV:
+		reader := csv.NewReader(r)
+		reader.FieldsPerRecord = -1
+		reader.TrimLeadingSpace = true
+		for first := true; ; first = false {
+			record, err := reader.Read()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				return ImportedWords{}, err
+			}
+			line, _ := reader.FieldPos(0)
+
+			if first && strings.EqualFold(strings.TrimSpace(record[0]), "word") {
+				continue
+			}
+			if len(record) > 2 {
+				c.reject(line, strings.Join(record, ","), ErrTooManyColumns.Error())
+				continue
+			}
+			if c.add(line, record[0]) && len(record) == 2 {
+				if category := normalizeWord(record[1]); category != "" {
+					if c.Categories == nil {
+						c.WordCategories = make(map[string]string)
+						c.Categories = make(map[string]int)
+					}
+					c.WordCategories[c.Words[len(c.Words)-1]] = category
+					c.Categories[category]++
+				}
+			}
+		}
+	case WordpackFormatJSON:
+		var doc struct {
+			Name        string   `json:"name"`
+			Description string   `json:"description"`
+			Words       []string `json:"words"`
+		}
+		if err := json.NewDecoder(r).Decode(&doc); err != nil {
+			return ImportedWords{}, fmt.Errorf("body contains badly-formed JSON: %w", err)
+		}
+		c.Name, c.Description = doc.Name, doc.Description
+		for i, word := range doc.Words {
+			c.add(i+1, word)
+		}
+	default:
+		return ImportedWords{}, ErrUnknownWordpackFormat
+	}
+
+	return c.ImportedWords, nil
+}
+
+// importFormat takes the format query parameter, falling back to the media
+// type of the body.
+func importFormat(r *http.Request) (WordpackFormat, error) {
+	if raw := r.URL.Query().Get("format"); raw != "" {
+		return WordpackFormat(raw), nil
+	}
+
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return "", ErrUnknownWordpackFormat
+	}
+	switch mediaType {
+	case "text/plain":
+		return WordpackFormatText, nil
+	case "text/csv":
+		return WordpackFormatCSV, nil
+	case "application/json":
+		return WordpackFormatJSON, nil
+	default:
+		return "", ErrUnknownWordpackFormat
+	}
+}
+
+// WordpackImport reports what an import kept and left out.
+type WordpackImport struct {
+	Wordpack   *WordpackInfo  `json:"wordpack,omitempty"`
+	Imported   int            `json:"imported"`
+	Rejected   []RejectedLine `json:"rejected"`
+	Categories map[string]int `json:"categories,omitempty"`
+	validator.Validator
+}
+
+// importWordpack creates a wordpack from a word list. Plain text and CSV take
+// the name and description from the query, JSON documents may carry them.
+func (s *Server) importWordpack(w http.ResponseWriter, r *http.Request) {
+	user, ok := contextGetAuthenticatedUser(r)
+	if !ok {
+		s.serverError(w, r, errors.New("failed to retrieve user data from request context"))
+		return
+	}
+
+	format, err := importFormat(r)
+	if err != nil {
+		s.badRequest(w, r, err)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, MaxImportBytes)
+	parsed, err := parseWordList(format, r.Body)
+	var maxBytesError *http.MaxBytesError
+	switch {
+	case errors.As(err, &maxBytesError):
+		s.badRequest(w, r, fmt.Errorf("body must not be larger than %d bytes", maxBytesError.Limit))
+		return
+	case err != nil:
+		s.badRequest(w, r, err)
+		return
+	}
+
+	query := r.URL.Query()
+	input := WordpackInput{Name: parsed.Name, Description: parsed.Description, Words: parsed.Words}
+	if raw := query.Get("name"); raw != "" {
+		input.Name = raw
+	}
+	if raw := query.Get("description"); raw != "" {
+		input.Description = raw
+	}
+	input.normalize()
+
+	report := WordpackImport{
+		Imported:   len(parsed.Words),
+		Rejected:   parsed.Rejected,
+		Categories: parsed.Categories,
+	}
+
+	// Rejected lines are kept in the report, so they can be fixed and the
+	// import retried.
+	minWords := MinImportWords()
+	report.CheckField(len(input.Words) >= minWords, "words", fmt.Sprintf("Must have at least %d words to fill a board, %d were accepted", minWords, len(input.Words)))
+	if report.HasErrors() {
+		err := response.JSON(w, http.StatusUnprocessableEntity, report)
+		if err != nil {
+			s.serverError(w, r, err)
+		}
+		return
+	}
+
+	if !s.checkWordpackInput(w, r, input, 0) {
+		return
+	}
+
+	wp, err := s.insertWordpack(r.Context(), user.ID, input, parsed.WordCategories)
+	if isUniqueViolation(err) {
+		s.wordpackNameTakenError(w, r)
+		return
+	}
+	if err != nil {
+		s.serverError(w, r, err)
+		return
+	}
+	info := wordpackInfo(wp, false)
+	report.Wordpack = &info
+
+	err = response.JSON(w, http.StatusCreated, report)
+	if err != nil {
+		s.serverError(w, r, err)
+	}
+}
+
+// exportFilename keeps the wordpack name readable in a download.
+func exportFilename(name string, format WordpackFormat) string {
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_' {
+			return r
+		}
+		return '-'
+	}, name)
+	return name + "." + string(format)
+}
+
+// escapeTextWord keeps words starting with # or a backslash from being read
+// back as comments or losing their backslash in a plain text import.
+func escapeTextWord(word string) string {
+	if strings.HasPrefix(word, "#") || strings.HasPrefix(word, `\`) {
+		return `\` + word
+	}
+	return word
+}
+
+// exportWordpack writes a wordpack in a format importWordpack reads back.
+func (s *Server) exportWordpack(w http.ResponseWriter, r *http.Request) {
+	format := WordpackFormat(r.URL.Query().Get("format"))
+	if format == "" {
+		format = WordpackFormatJSON
+	}
+	if !validator.In(format, WordpackFormatText, WordpackFormatCSV, WordpackFormatJSON) {
+		s.badRequest(w, r, ErrUnknownWordpackFormat)
+		return
+	}
+
+	wp, ok := s.wordpackFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	disposition := mime.FormatMediaType("attachment", map[string]string{"filename": exportFilename(wp.Name, format)})
+	w.Header().Set("Content-Disposition", disposition)
+
+	switch format {
+	case WordpackFormatText:
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		for _, word := range wp.Words {
+			fmt.Fprintln(w, escapeTextWord(word))
+		}
+	case WordpackFormatCSV:
+		rows, err := s.db.Queries.ListWordpackCategories(r.Context(), wp.ID)
+		if err != nil {
+			s.serverError(w, r, err)
+			return
+		}
+		categories := make(map[string]string, len(rows))
+		for _, row := range rows {
+			categories[row.Word] = row.Category
+		}
+
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		cw := csv.NewWriter(w)
+		if len(categories) == 0 {
+			cw.Write([]string{"word"})
+		} else {
+			cw.Write([]string{"word", "category"})
+		}
+		for _, word := range wp.Words {
+			if len(categories) == 0 {
+				cw.Write([]string{word})
+			} else {
+				cw.Write([]string{word, categories[word]})
+			}
+		}
+		cw.Flush()
+		if err := cw.Error(); err != nil {
+			s.reportServerError(r, err)
+		}
+	case WordpackFormatJSON:
+		doc := WordpackInput{Name: wp.Name, Description: wp.Description.String, Words: wp.Words}
+		err := response.JSON(w, http.StatusOK, doc)
+		if err != nil {
+			s.serverError(w, r, err)
+		}
+	}
+}
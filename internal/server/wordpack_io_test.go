@@ -0,0 +1,49 @@
+package server
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseWordList(t *testing.T) {
+	text := "# animals\n  Cat \n\ndog\nCAT\nsnow   leopard\ncaf\u00e9\ncafe\u0301\n"
+	parsed, err := parseWordList(WordpackFormatText, strings.NewReader(text))
+	if err != nil {
+		t.Fatalf("parse text: %v", err)
+	}
+	want := []string{"Cat", "dog", "snow leopard", "caf\u00e9"}
+	if strings.Join(parsed.Words, "|") != strings.Join(want, "|") {
+		t.Errorf("expected words %q; got %q", want, parsed.Words)
+	}
+	if len(parsed.Rejected) != 2 || parsed.Rejected[0].Line != 5 || parsed.Rejected[0].Reason != rejectDuplicate {
+		t.Errorf("expected the repeated words to be rejected; got %+v", parsed.Rejected)
+	}
+
+	csv := "\ufeffword,category\napple,fruit\npear,fruit\nrock,thing,extra\n"
+	parsed, err = parseWordList(WordpackFormatCSV, strings.NewReader(csv))
+	if err != nil {
+		t.Fatalf("parse csv: %v", err)
+	}
+	if len(parsed.Words) != 2 || parsed.Categories["fruit"] != 2 || parsed.WordCategories["pear"] != "fruit" {
+		t.Errorf("expected two fruit; got %q %v", parsed.Words, parsed.WordCategories)
+	}
+	if len(parsed.Rejected) != 1 || parsed.Rejected[0].Line != 4 {
+		t.Errorf("expected the row with extra columns to be rejected; got %+v", parsed.Rejected)
+	}
+}
+
+func TestTextExportRoundTrip(t *testing.T) {
+	words := []string{"#1", `\n`, "plain"}
+
+	var export strings.Builder
+	for _, word := range words {
+		export.WriteString(escapeTextWord(word) + "\n")
+	}
+	parsed, err := parseWordList(WordpackFormatText, strings.NewReader(export.String()))
+	if err != nil {
+		t.Fatalf("parse text: %v", err)
+	}
+	if strings.Join(parsed.Words, "|") != strings.Join(words, "|") {
+		t.Errorf("expected exported words to import unchanged; got %q", parsed.Words)
+	}
+}
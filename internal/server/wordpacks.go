@@ -0,0 +1,411 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/ninox14/gore-codenames/internal/database/sqlc"
+	"github.com/ninox14/gore-codenames/internal/request"
+	"github.com/ninox14/gore-codenames/internal/response"
+	"github.com/ninox14/gore-codenames/internal/validator"
+)
+
+const (
+	DefaultWordpackPageSize = 20
+	MaxWordpackPageSize     = 100
+	MaxWordpackNameLen      = 256
+	MaxWordpackDescLen      = 300
+)
+
+var (
+	ErrDefaultWordpack   = errors.New("default wordpacks are read-only")
+	ErrNotWordpackOwner  = errors.New("only the creator of a wordpack can change it")
+	ErrWordpackInUse     = errors.New("the wordpack is used by games and cannot be deleted")
+	ErrInvalidWordpackID = errors.New("invalid wordpack id")
+)
+
+// WordpackInfo is a wordpack as the API shows it. Lists leave out Words.
+type WordpackInfo struct {
+	ID          int32      `json:"id"`
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	CreatedBy   *uuid.UUID `json:"created_by"`
+	IsDefault   bool       `json:"is_default"`
+	WordCount   int        `json:"word_count"`
+	Words       []string   `json:"words,omitempty"`
+}
+
+func wordpackInfo(wp sqlc.Wordpack, withWords bool) WordpackInfo {
+	info := WordpackInfo{
+		ID:          wp.ID,
+		Name:        wp.Name,
+		Description: wp.Description.String,
+		CreatedBy:   wp.CreatedBy,
+		IsDefault:   wp.IsDefault.Bool,
+		WordCount:   len(wp.Words),
+	}
+	if withWords {
+		info.Words = wp.Words
+	}
+	return info
+}
+
+// WordpackPage is one page of wordpacks with the total across all pages.
+type WordpackPage struct {
+	Wordpacks []WordpackInfo `json:"wordpacks"`
+	Total     int64          `json:"total"`
+	Limit     int32          `json:"limit"`
+	Offset    int32          `json:"offset"`
+}
+
+// WordpackInput is what clients send to create or replace a wordpack.
+type WordpackInput struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Words       []string `json:"words"`
+}
+
+func (in *WordpackInput) normalize() {
+	in.Name = strings.TrimSpace(in.Name)
+	in.Description = strings.TrimSpace(in.Description)
+	for i, word := range in.Words {
+		in.Words[i] = normalizeWord(word)
+	}
+}
+
+func (in WordpackInput) validate(v *validator.Validator) {
+	v.CheckField(validator.NotBlank(in.Name), "name", "Name is required")
+	v.CheckField(validator.MaxRunes(in.Name, MaxWordpackNameLen), "name", fmt.Sprintf("Must not be more than %d characters long", MaxWordpackNameLen))
+	v.CheckField(validator.MaxRunes(in.Description, MaxWordpackDescLen), "description", fmt.Sprintf("Must not be more than %d characters long", MaxWordpackDescLen))
+	v.CheckField(len(in.Words) > 0, "words", "Words are required")
+}
+
+// pageParams reads limit and offset from the query.
+func pageParams(r *http.Request, v *validator.Validator) (limit, offset int32) {
+	query := r.URL.Query()
+	limit = DefaultWordpackPageSize
+
+	if raw := query.Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		v.CheckField(err == nil && validator.Between(n, 1, MaxWordpackPageSize), "limit", fmt.Sprintf("Must be between 1 and %d", MaxWordpackPageSize))
+		limit = int32(n)
+	}
+	if raw := query.Get("offset"); raw != "" {
+		n, err := strconv.ParseInt(raw, 10, 32)
+		v.CheckField(err == nil && n >= 0, "offset", "Must be zero or more")
+		offset = int32(n)
+	}
+	return limit, offset
+}
+
+func (s *Server) listWordpacks(w http.ResponseWriter, r *http.Request) {
+	var v validator.Validator
+	limit, offset := pageParams(r, &v)
+	if v.HasErrors() {
+		s.failedValidation(w, r, v)
+		return
+	}
+
+	rows, err := s.db.Queries.ListWordpacks(r.Context(), sqlc.ListWordpacksParams{Limit: limit, Offset: offset})
+	if err != nil {
+		s.serverError(w, r, err)
+		return
+	}
+	total, err := s.db.Queries.CountWordpacks(r.Context())
+	if err != nil {
+		s.serverError(w, r, err)
+		return
+	}
+
+	s.writeWordpackPage(w, r, rows, total, limit, offset)
+}
+
+// searchWordpacks matches q anywhere in the name or description.
+func (s *Server) searchWordpacks(w http.ResponseWriter, r *http.Request) {
+	var v validator.Validator
+	limit, offset := pageParams(r, &v)
+	q := strings.TrimSpace(r.URL.Query().Get("q"))
+	v.CheckField(q != "", "q", "Search term is required")
+	if v.HasErrors() {
+		s.failedValidation(w, r, v)
+		return
+	}
+
+	escaped := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(q)
+	pattern := "%" + escaped + "%"
+
+	rows, err := s.db.Queries.SearchWordpacks(r.Context(), sqlc.SearchWordpacksParams{Name: pattern, Limit: limit, Offset: offset})
+	if err != nil {
+		s.serverError(w, r, err)
+		return
+	}
+	total, err := s.db.Queries.CountSearchWordpacks(r.Context(), pattern)
+	if err != nil {
+		s.serverError(w, r, err)
+		return
+	}
+
+	s.writeWordpackPage(w, r, rows, total, limit, offset)
+}
+
+func (s *Server) writeWordpackPage(w http.ResponseWriter, r *http.Request, rows []sqlc.Wordpack, total int64, limit, offset int32) {
+	page := WordpackPage{
+		Wordpacks: make([]WordpackInfo, len(rows)),
+		Total:     total,
+		Limit:     limit,
+		Offset:    offset,
+	}
+	for i, row := range rows {
+		page.Wordpacks[i] = wordpackInfo(row, false)
+	}
+
+	err := response.JSON(w, http.StatusOK, page)
+	if err != nil {
+		s.serverError(w, r, err)
+	}
+}
+
+// wordpackFromPath loads the wordpack named by the id path value and writes
+// the error response if there is none.
+func (s *Server) wordpackFromPath(w http.ResponseWriter, r *http.Request) (sqlc.Wordpack, bool) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 32)
+	if err != nil {
+		s.badRequest(w, r, ErrInvalidWordpackID)
+		return sqlc.Wordpack{}, false
+	}
+
+	wp, err := s.db.Queries.GetWordpack(r.Context(), int32(id))
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		s.notFound(w, r)
+		return sqlc.Wordpack{}, false
+	case err != nil:
+		s.serverError(w, r, err)
+		return sqlc.Wordpack{}, false
+	}
+	return wp, true
+}
+
+// checkWordpackOwner writes a 403 unless the user may change the wordpack.
+func (s *Server) checkWordpackOwner(w http.ResponseWriter, r *http.Request, wp sqlc.Wordpack, userID uuid.UUID) bool {
+	if wp.IsDefault.Bool {
+		s.accessDenied(w, r, ErrDefaultWordpack)
+		return false
+	}
+	if wp.CreatedBy == nil || *wp.CreatedBy != userID {
+		s.accessDenied(w, r, ErrNotWordpackOwner)
+		return false
+	}
+	return true
+}
+
+func (s *Server) getWordpack(w http.ResponseWriter, r *http.Request) {
+	wp, ok := s.wordpackFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	err := response.JSON(w, http.StatusOK, wordpackInfo(wp, true))
+	if err != nil {
+		s.serverError(w, r, err)
+	}
+}
+
+// decodeWordpackInput reads and validates a wordpack, checking its name
+// against every pack but the one with id.
+func (s *Server) decodeWordpackInput(w http.ResponseWriter, r *http.Request, id int32) (WordpackInput, bool) {
+	var input WordpackInput
+	err := request.DecodeJSON(w, r, &input)
+	if err != nil {
+		s.badRequest(w, r, err)
+		return input, false
+	}
+	input.normalize()
+
+	return input, s.checkWordpackInput(w, r, input, id)
+}
+
+const wordpackNameTaken = "A wordpack with this name already exists"
+
+// wordpackNameTakenError reports a name that was taken between the check in
+// checkWordpackInput and the write, the same way the check would have.
+func (s *Server) wordpackNameTakenError(w http.ResponseWriter, r *http.Request) {
+	var v validator.Validator
+	v.AddFieldError("name", wordpackNameTaken)
+	s.failedValidation(w, r, v)
+}
+
+// checkWordpackInput writes the validation errors of input, if any. Word
+// warnings do not keep a pack from being saved.
+func (s *Server) checkWordpackInput(w http.ResponseWriter, r *http.Request, input WordpackInput, id int32) bool {
+	v := checkWords(input.Words).Errors
+	input.validate(&v)
+	if v.HasErrors() {
+		s.failedValidation(w, r, v)
+		return false
+	}
+
+	taken, err := s.db.Queries.CheckWordpackNameExists(r.Context(), sqlc.CheckWordpackNameExistsParams{Name: input.Name, ID: id})
+	if err != nil {
+		s.serverError(w, r, err)
+		return false
+	}
+	v.CheckField(!taken, "name", wordpackNameTaken)
+	if v.HasErrors() {
+		s.failedValidation(w, r, v)
+		return false
+	}
+	return true
+}
+
+// insertWordpack stores a new, never default, wordpack owned by the user,
+// along with the categories of its words, if there are any.
+func (s *Server) insertWordpack(ctx context.Context, userID uuid.UUID, input WordpackInput, categories map[string]string) (sqlc.Wordpack, error) {
+	var wp sqlc.Wordpack
+	err := s.db.WithTx(ctx, func(q *sqlc.Queries) error {
+		var err error
+		wp, err = q.CreateWordpack(ctx, sqlc.CreateWordpackParams{
+			Name:        input.Name,
+			Description: pgtype.Text{String: input.Description, Valid: input.Description != ""},
+			CreatedBy:   &userID,
+			IsDefault:   pgtype.Bool{Bool: false, Valid: true},
+			Words:       input.Words,
+		})
+		if err != nil || len(categories) == 0 {
+			return err
+		}
+
+		params := sqlc.InsertWordpackCategoriesParams{WordpackID: wp.ID}
+		for _, word := range input.Words {
+			if category, ok := categories[word]; ok {
+				params.Words = append(params.Words, word)
+				params.Categories = append(params.Categories, category)
+			}
+		}
+		return q.InsertWordpackCategories(ctx, params)
+	})
+	return wp, err
+}
+
+func (s *Server) createWordpack(w http.ResponseWriter, r *http.Request) {
+	user, ok := contextGetAuthenticatedUser(r)
+	if !ok {
+		s.serverError(w, r, errors.New("failed to retrieve user data from request context"))
+		return
+	}
+
+	input, ok := s.decodeWordpackInput(w, r, 0)
+	if !ok {
+		return
+	}
+
+	wp, err := s.insertWordpack(r.Context(), user.ID, input, nil)
+	if isUniqueViolation(err) {
+		s.wordpackNameTakenError(w, r)
+		return
+	}
+	if err != nil {
+		s.serverError(w, r, err)
+		return
+	}
+
+	err = response.JSON(w, http.StatusCreated, wordpackInfo(wp, true))
+	if err != nil {
+		s.serverError(w, r, err)
+	}
+}
+
+// updateWordpack replaces the name, description and words of a pack.
+func (s *Server) updateWordpack(w http.ResponseWriter, r *http.Request) {
+	user, ok := contextGetAuthenticatedUser(r)
+	if !ok {
+		s.serverError(w, r, errors.New("failed to retrieve user data from request context"))
+		return
+	}
+
+	wp, ok := s.wordpackFromPath(w, r)
+	if !ok || !s.checkWordpackOwner(w, r, wp, user.ID) {
+		return
+	}
+
+	input, ok := s.decodeWordpackInput(w, r, wp.ID)
+	if !ok {
+		return
+	}
+
+	// Categories of words that were removed go with them.
+	err := s.db.WithTx(r.Context(), func(q *sqlc.Queries) error {
+		var err error
+		wp, err = q.UpdateWordpack(r.Context(), sqlc.UpdateWordpackParams{
+			ID:          wp.ID,
+			Name:        input.Name,
+			Description: pgtype.Text{String: input.Description, Valid: input.Description != ""},
+			IsDefault:   wp.IsDefault,
+			Words:       input.Words,
+		})
+		if err != nil {
+			return err
+		}
+		return q.DeleteStaleWordpackCategories(r.Context(), sqlc.DeleteStaleWordpackCategoriesParams{WordpackID: wp.ID, Words: input.Words})
+	})
+	if isUniqueViolation(err) {
+		s.wordpackNameTakenError(w, r)
+		return
+	}
+	if err != nil {
+		s.serverError(w, r, err)
+		return
+	}
+
+	err = response.JSON(w, http.StatusOK, wordpackInfo(wp, true))
+	if err != nil {
+		s.serverError(w, r, err)
+	}
+}
+
+func (s *Server) deleteWordpack(w http.ResponseWriter, r *http.Request) {
+	user, ok := contextGetAuthenticatedUser(r)
+	if !ok {
+		s.serverError(w, r, errors.New("failed to retrieve user data from request context"))
+		return
+	}
+
+	wp, ok := s.wordpackFromPath(w, r)
+	if !ok || !s.checkWordpackOwner(w, r, wp, user.ID) {
+		return
+	}
+
+	err := s.db.Queries.DeleteWordpack(r.Context(), wp.ID)
+	if isForeignKeyViolation(err) {
+		s.errorMessage(w, r, http.StatusConflict, ErrWordpackInUse.Error(), nil)
+		return
+	}
+	if err != nil {
+		s.serverError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// isForeignKeyViolation reports whether err is a row still being referenced,
+// e.g. a wordpack games were played with.
+func isForeignKeyViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23503"
+}
+
+// isUniqueViolation reports whether err is a duplicate of a unique column,
+// e.g. a wordpack name taken since it was checked.
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
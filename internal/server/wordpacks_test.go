@@ -0,0 +1,50 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/ninox14/gore-codenames/internal/validator"
+)
+
+func TestWordpackInputValidation(t *testing.T) {
+	input := WordpackInput{Name: "  Animals ", Words: []string{" cat", "dog ", "snow  owl"}}
+	input.normalize()
+
+	var v validator.Validator
+	input.validate(&v)
+	if v.HasErrors() {
+		t.Fatalf("expected a valid wordpack; got %v", v.FieldErrors)
+	}
+	if input.Name != "Animals" || input.Words[0] != "cat" || input.Words[2] != "snow owl" {
+		t.Errorf("expected input to be trimmed; got %q %q", input.Name, input.Words)
+	}
+
+	input = WordpackInput{Name: " "}
+	input.normalize()
+
+	v = validator.Validator{}
+	input.validate(&v)
+	for _, field := range []string{"name", "words"} {
+		if _, ok := v.FieldErrors[field]; !ok {
+			t.Errorf("expected an error for %s; got %v", field, v.FieldErrors)
+		}
+	}
+}
+
+func TestWordpackNameRaceIsValidationError(t *testing.T) {
+	err := fmt.Errorf("insert: %w", &pgconn.PgError{Code: "23505"})
+	if !isUniqueViolation(err) || isUniqueViolation(&pgconn.PgError{Code: "23503"}) {
+		t.Fatalf("expected only unique violations to be recognised")
+	}
+
+	w := httptest.NewRecorder()
+	(&Server{}).wordpackNameTakenError(w, httptest.NewRequest(http.MethodPost, "/wordpacks", nil))
+	if w.Code != http.StatusUnprocessableEntity || !strings.Contains(w.Body.String(), wordpackNameTaken) {
+		t.Errorf("expected the same 422 as the name check; got %d %s", w.Code, w.Body.String())
+	}
+}